@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/batku/beerreal/internal/config"
+	"github.com/batku/beerreal/internal/database"
+	"github.com/batku/beerreal/internal/models"
+	"github.com/batku/beerreal/internal/repository"
+	"github.com/batku/beerreal/internal/service"
+)
+
+// Usage: go run cmd/import-catalog/main.go <catalog.json|catalog.csv>
+//
+// JSON files contain an array of catalog entries:
+//
+//	[{"name": "Guinness Draught", "brewery": "Guinness", "breweryCountry": "IE",
+//	  "style": "Stout", "abv": 4.2, "aliases": ["guiness draught"]}]
+//
+// CSV files have a header row with the columns id, name, brewery,
+// breweryCountry, style, abv and aliases (aliases separated by "|"). Only
+// name is required.
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal("Usage: import-catalog <catalog.json|catalog.csv>")
+	}
+	path := os.Args[1]
+
+	entries, err := readCatalogFile(path)
+	if err != nil {
+		log.Fatalf("Failed to read catalog file: %v", err)
+	}
+
+	cfg := config.LoadConfig()
+
+	// Initialize database
+	db, err := database.NewDatabase(cfg.DatabasePath)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	catalogService := service.NewCatalogService(repository.NewCatalogRepository(db.DB), repository.NewPostRepository(db.DB, cfg.VenueRegularThreshold))
+	breweries, beers, err := catalogService.Import(entries)
+	if err != nil {
+		log.Fatalf("Failed to import catalog: %v", err)
+	}
+
+	log.Printf("Imported %d beers from %d breweries", beers, breweries)
+}
+
+func readCatalogFile(path string) ([]models.CatalogEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		var entries []models.CatalogEntry
+		if err := json.NewDecoder(file).Decode(&entries); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		return entries, nil
+	case ".csv":
+		return readCatalogCSV(file)
+	default:
+		return nil, fmt.Errorf("unsupported file type %q, expected .json or .csv", filepath.Ext(path))
+	}
+}
+
+func readCatalogCSV(r io.Reader) ([]models.CatalogEntry, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, fmt.Errorf("CSV header must contain a name column")
+	}
+
+	entries := []models.CatalogEntry{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		entry := models.CatalogEntry{
+			ID:             field("id"),
+			Name:           field("name"),
+			Brewery:        field("brewery"),
+			BreweryCountry: field("breweryCountry"),
+			Style:          field("style"),
+		}
+		if abv := field("abv"); abv != "" {
+			value, err := strconv.ParseFloat(abv, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid abv %q", line, abv)
+			}
+			entry.ABV = &value
+		}
+		if aliases := field("aliases"); aliases != "" {
+			entry.Aliases = strings.Split(aliases, "|")
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
@@ -2,11 +2,15 @@ package main
 
 import (
 	"log"
+	"time"
 
 	"github.com/batku/beerreal/internal/config"
 	"github.com/batku/beerreal/internal/database"
+	"github.com/batku/beerreal/internal/events"
 	"github.com/batku/beerreal/internal/handlers"
 	"github.com/batku/beerreal/internal/middleware"
+	"github.com/batku/beerreal/internal/models"
+	"github.com/batku/beerreal/internal/push"
 	"github.com/batku/beerreal/internal/repository"
 	"github.com/batku/beerreal/internal/service"
 	"github.com/gin-gonic/gin"
@@ -33,17 +37,79 @@ func main() {
 	}
 
 	// Initialize repository, service, and handler layers
-	postRepo := repository.NewPostRepository(db.DB)
+	postRepo := repository.NewPostRepository(db.DB, cfg.VenueRegularThreshold)
 	userRepo := repository.NewUserRepository(db.DB)
+	relationRepo := repository.NewRelationshipRepository(db.DB)
+	catalogRepo := repository.NewCatalogRepository(db.DB)
+	venueRepo := repository.NewVenueRepository(db.DB)
+	achievementRepo := repository.NewAchievementRepository(db.DB)
+	notificationRepo := repository.NewNotificationRepository(db.DB)
+	deviceRepo := repository.NewDeviceRepository(db.DB)
+	outboxRepo := repository.NewOutboxRepository(db.DB)
+	reactionRepo := repository.NewReactionRepository(db.DB)
+
+	bus := events.NewBus()
+	outboxDispatcher := service.NewOutboxDispatcher(outboxRepo, 8, 2*time.Second)
+
+	achievementService := service.NewAchievementService(achievementRepo)
+	achievementService.Subscribe(bus)
+	achievementHandler := handlers.NewAchievementHandler(achievementService)
+
+	// Without FCM the server still runs; notifications only go to the inbox
+	var dispatcher service.NotificationDispatcher
+	if cfg.PushEnabled {
+		sender, err := push.NewFCMSender(cfg.FirebaseCredentialsPath)
+		if err != nil {
+			log.Printf("Failed to initialize FCM, push notifications disabled: %v", err)
+		} else {
+			pushWorker := service.NewPushWorker(deviceRepo, sender, 5, 2*time.Second)
+			pushWorker.Start(4)
+			dispatcher = pushWorker
+		}
+	}
+
+	notificationService := service.NewNotificationService(notificationRepo, deviceRepo, userRepo, postRepo, relationRepo, dispatcher)
+	notificationService.Subscribe(bus)
+	if dispatcher != nil {
+		go func() {
+			if err := notificationService.RunDailyMomentJob(cfg.DailyMomentStartHour, cfg.DailyMomentEndHour); err != nil {
+				log.Printf("Daily moment push disabled: %v", err)
+			}
+		}()
+	}
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
 
-	postService := service.NewPostService(postRepo, userRepo)
+	postService := service.NewPostService(postRepo, userRepo, relationRepo, catalogRepo, venueRepo, bus, outboxDispatcher, service.LocationFuzzer{
+		RoundingDecimals: cfg.LocationRoundingDecimals,
+		JitterMeters:     cfg.LocationJitterMeters,
+	})
 	postHandler := handlers.NewPostHandler(postService)
 
-	userService := service.NewUserService(userRepo)
+	userService := service.NewUserService(userRepo, postRepo, relationRepo, reactionRepo, achievementService, notificationService, bus)
 	userHandler := handlers.NewUserHandler(userService)
+	go userService.RunStreakResetJob(cfg.StreakResetHour)
+
+	outboxDispatcher.Handle(models.OutboxTypeVoteCast, userService.ApplyTasteScoreChange)
+	go outboxDispatcher.Run(time.Second)
+
+	catalogService := service.NewCatalogService(catalogRepo, postRepo)
+	catalogHandler := handlers.NewCatalogHandler(catalogService)
+
+	venueService := service.NewVenueService(venueRepo, postRepo)
+	venueHandler := handlers.NewVenueHandler(venueService)
+
+	reactionService := service.NewReactionService(reactionRepo, postRepo, relationRepo)
+	reactionHandler := handlers.NewReactionHandler(reactionService)
+
+	voteService := service.NewVoteService(postRepo, cfg.VoterListPublic)
+	voteHandler := handlers.NewVoteHandler(voteService)
+
+	streamService := service.NewStreamService(bus, postRepo, relationRepo)
+	streamHandler := handlers.NewStreamHandler(streamService)
 
 	// Setup router
-	router := gin.Default()
+	router := gin.New()
+	router.Use(middleware.RequestLogger(), gin.Recovery())
 
 	// CORS middleware
 	router.Use(func(c *gin.Context) {
@@ -74,7 +140,21 @@ func main() {
 		// Register post routes
 		postHandler.RegisterRoutes(api, firebaseAuth.AuthMiddleware(), firebaseAuth.OptionalAuthMiddleware())
 		// Register user routes
-		userHandler.RegisterRoutes(api, firebaseAuth.AuthMiddleware())
+		userHandler.RegisterRoutes(api, firebaseAuth.AuthMiddleware(), firebaseAuth.OptionalAuthMiddleware())
+		// Register beer catalog routes
+		catalogHandler.RegisterRoutes(api, firebaseAuth.OptionalAuthMiddleware())
+		// Register achievement routes
+		achievementHandler.RegisterRoutes(api, firebaseAuth.AuthMiddleware())
+		// Register notification routes
+		notificationHandler.RegisterRoutes(api, firebaseAuth.AuthMiddleware())
+		// Register venue routes
+		venueHandler.RegisterRoutes(api, firebaseAuth.AuthMiddleware(), firebaseAuth.OptionalAuthMiddleware())
+		// Register reaction routes
+		reactionHandler.RegisterRoutes(api, firebaseAuth.AuthMiddleware(), firebaseAuth.OptionalAuthMiddleware())
+		// Register vote listing routes
+		voteHandler.RegisterRoutes(api, firebaseAuth.AuthMiddleware(), firebaseAuth.OptionalAuthMiddleware())
+		// Register real-time stream route
+		streamHandler.RegisterRoutes(api, firebaseAuth.StreamAuthMiddleware())
 	}
 
 	// Start server
@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
 )
 
 type Config struct {
@@ -10,14 +11,43 @@ type Config struct {
 	DatabasePath            string
 	FirebaseCredentialsPath string
 	GinMode                 string
+	// LocationRoundingDecimals is the number of decimal places post
+	// coordinates are rounded to before storage; negative disables rounding.
+	LocationRoundingDecimals int
+	// LocationJitterMeters randomly offsets post coordinates by up to this
+	// distance before rounding; zero disables jitter.
+	LocationJitterMeters float64
+	// VenueRegularThreshold is how many posts an author needs at a venue to be
+	// badged as a regular there; zero disables the badge.
+	VenueRegularThreshold int
+	// StreakResetHour is the UTC hour at which broken posting streaks are
+	// reset each night.
+	StreakResetHour int
+	// PushEnabled turns on push notification delivery through FCM.
+	PushEnabled bool
+	// DailyMomentStartHour and DailyMomentEndHour bound the local time of day
+	// at which the daily moment push is sent.
+	DailyMomentStartHour int
+	DailyMomentEndHour   int
+	// VoterListPublic lets everyone who can see a post list its voters;
+	// otherwise only the post's author can.
+	VoterListPublic bool
 }
 
 func LoadConfig() *Config {
 	return &Config{
-		Port:                    getEnv("PORT", "8080"),
-		DatabasePath:            getEnv("DATABASE_PATH", "./beerreal.db"),
-		FirebaseCredentialsPath: getEnv("FIREBASE_CREDENTIALS_PATH", "./firebase-credentials.json"),
-		GinMode:                 getEnv("GIN_MODE", "debug"),
+		Port:                     getEnv("PORT", "8080"),
+		DatabasePath:             getEnv("DATABASE_PATH", "./beerreal.db"),
+		FirebaseCredentialsPath:  getEnv("FIREBASE_CREDENTIALS_PATH", "./firebase-credentials.json"),
+		GinMode:                  getEnv("GIN_MODE", "debug"),
+		LocationRoundingDecimals: getEnvInt("LOCATION_ROUNDING_DECIMALS", 3),
+		LocationJitterMeters:     getEnvFloat("LOCATION_JITTER_METERS", 0),
+		VenueRegularThreshold:    getEnvInt("VENUE_REGULAR_THRESHOLD", 5),
+		StreakResetHour:          getEnvInt("STREAK_RESET_HOUR", 3),
+		PushEnabled:              getEnv("PUSH_ENABLED", "true") == "true",
+		DailyMomentStartHour:     getEnvInt("DAILY_MOMENT_START_HOUR", 10),
+		DailyMomentEndHour:       getEnvInt("DAILY_MOMENT_END_HOUR", 22),
+		VoterListPublic:          getEnv("VOTER_LIST_PUBLIC", "false") == "true",
 	}
 }
 
@@ -31,3 +61,29 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid integer for %s: %q, using default %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Invalid number for %s: %q, using default %g", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
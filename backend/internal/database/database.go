@@ -4,7 +4,10 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"time"
 
+	"github.com/batku/beerreal/internal/models"
+	"github.com/batku/beerreal/internal/streak"
 	_ "github.com/mattn/go-sqlite3"
 )
 
@@ -87,6 +90,158 @@ func (d *Database) migrate() error {
 		)`,
 		`CREATE INDEX IF NOT EXISTS idx_votes_post_id ON votes(post_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_votes_user_id ON votes(user_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_votes_post_updated_at ON votes(post_id, updated_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_votes_user_updated_at ON votes(user_id, updated_at)`,
+		`CREATE TABLE IF NOT EXISTS user_restrictions (
+			user_id TEXT NOT NULL,
+			target_user_id TEXT NOT NULL,
+			restriction_type TEXT NOT NULL CHECK(restriction_type IN ('BLOCK', 'MUTE')),
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, target_user_id),
+			FOREIGN KEY (user_id) REFERENCES users(id),
+			FOREIGN KEY (target_user_id) REFERENCES users(id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_user_restrictions_target ON user_restrictions(target_user_id)`,
+		`CREATE TABLE IF NOT EXISTS friendships (
+			user_id TEXT NOT NULL,
+			friend_id TEXT NOT NULL,
+			status TEXT NOT NULL CHECK(status IN ('PENDING', 'ACCEPTED')),
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, friend_id),
+			FOREIGN KEY (user_id) REFERENCES users(id),
+			FOREIGN KEY (friend_id) REFERENCES users(id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_friendships_friend_id ON friendships(friend_id)`,
+		`CREATE TABLE IF NOT EXISTS breweries (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			country TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS beers (
+			id TEXT PRIMARY KEY,
+			brewery_id TEXT,
+			name TEXT NOT NULL,
+			style TEXT,
+			abv REAL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (brewery_id) REFERENCES breweries(id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_beers_brewery_id ON beers(brewery_id)`,
+		`CREATE TABLE IF NOT EXISTS beer_aliases (
+			alias TEXT PRIMARY KEY,
+			beer_id TEXT NOT NULL,
+			FOREIGN KEY (beer_id) REFERENCES beers(id) ON DELETE CASCADE
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_beer_aliases_beer_id ON beer_aliases(beer_id)`,
+		`CREATE TABLE IF NOT EXISTS venues (
+			id TEXT PRIMARY KEY,
+			place_id TEXT NOT NULL UNIQUE,
+			name TEXT NOT NULL,
+			address TEXT,
+			latitude REAL NOT NULL,
+			longitude REAL NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS taste_score_events (
+			id TEXT PRIMARY KEY,
+			user_id TEXT NOT NULL,
+			post_id TEXT NOT NULL,
+			voter_id TEXT NOT NULL,
+			vote_id TEXT NOT NULL,
+			delta INTEGER NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id),
+			FOREIGN KEY (post_id) REFERENCES beer_posts(id) ON DELETE CASCADE
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_taste_score_events_user_created ON taste_score_events(user_id, created_at)`,
+		// Seed the history from existing votes the first time the table is created
+		`INSERT INTO taste_score_events (id, user_id, post_id, voter_id, vote_id, delta, created_at)
+		SELECT 'vote:' || v.id, bp.user_id, v.post_id, v.user_id, v.id,
+		       CASE v.vote_type WHEN 'UPVOTE' THEN 1 ELSE -1 END, v.updated_at
+		FROM votes v
+		JOIN beer_posts bp ON v.post_id = bp.id
+		WHERE NOT EXISTS (SELECT 1 FROM taste_score_events)`,
+		`CREATE TABLE IF NOT EXISTS user_achievements (
+			user_id TEXT NOT NULL,
+			achievement_id TEXT NOT NULL,
+			unlocked_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, achievement_id),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS notifications (
+			id TEXT PRIMARY KEY,
+			user_id TEXT NOT NULL,
+			type TEXT NOT NULL CHECK(type IN ('VOTE', 'COMMENT', 'MENTION', 'FRIEND_REQUEST', 'FRIEND_ACCEPTED')),
+			actor_id TEXT NOT NULL,
+			post_id TEXT,
+			comment_id TEXT,
+			vote_type TEXT,
+			text TEXT,
+			read_at DATETIME,
+			created_at DATETIME NOT NULL,
+			FOREIGN KEY (user_id) REFERENCES users(id),
+			FOREIGN KEY (actor_id) REFERENCES users(id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_notifications_user_created ON notifications(user_id, created_at DESC, id DESC)`,
+		`CREATE TABLE IF NOT EXISTS devices (
+			token TEXT PRIMARY KEY,
+			user_id TEXT NOT NULL,
+			platform TEXT NOT NULL CHECK(platform IN ('ANDROID', 'IOS', 'WEB')),
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_devices_user_id ON devices(user_id)`,
+		`CREATE TABLE IF NOT EXISTS notification_settings (
+			user_id TEXT PRIMARY KEY,
+			votes INTEGER NOT NULL DEFAULT 1,
+			comments INTEGER NOT NULL DEFAULT 1,
+			mentions INTEGER NOT NULL DEFAULT 1,
+			friend_requests INTEGER NOT NULL DEFAULT 1,
+			friend_accepted INTEGER NOT NULL DEFAULT 1,
+			daily_moment INTEGER NOT NULL DEFAULT 1,
+			quiet_hours_start TEXT,
+			quiet_hours_end TEXT,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS outbox (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			type TEXT NOT NULL,
+			payload TEXT NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT,
+			next_attempt_at DATETIME NOT NULL,
+			created_at DATETIME NOT NULL,
+			dead_lettered_at DATETIME
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_outbox_due ON outbox(dead_lettered_at, next_attempt_at)`,
+		`CREATE TABLE IF NOT EXISTS reactions (
+			post_id TEXT NOT NULL,
+			user_id TEXT NOT NULL,
+			type TEXT NOT NULL CHECK(type IN ('CHEERS', 'BEER', 'SICK', 'LAUGH', 'LOVE', 'FIRE')),
+			created_at DATETIME NOT NULL,
+			PRIMARY KEY (post_id, user_id, type),
+			FOREIGN KEY (post_id) REFERENCES beer_posts(id),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_reactions_user_id ON reactions(user_id)`,
+		`CREATE TABLE IF NOT EXISTS realmojis (
+			id TEXT PRIMARY KEY,
+			post_id TEXT NOT NULL,
+			user_id TEXT NOT NULL,
+			image_data TEXT NOT NULL,
+			created_at DATETIME NOT NULL,
+			UNIQUE(post_id, user_id),
+			FOREIGN KEY (post_id) REFERENCES beer_posts(id),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_realmojis_user_id ON realmojis(user_id)`,
 	}
 
 	for _, migration := range migrations {
@@ -98,6 +253,138 @@ func (d *Database) migrate() error {
 	// Attempt to migrate old schema if it exists (ignore error if column doesn't exist)
 	d.DB.Exec("ALTER TABLE users RENAME COLUMN profile_image_url TO profile_image_data")
 
+	// Columns added after the initial schema (ignore error if column already exists)
+	d.DB.Exec("ALTER TABLE users ADD COLUMN is_private INTEGER NOT NULL DEFAULT 0")
+	d.DB.Exec("ALTER TABLE users ADD COLUMN time_zone TEXT NOT NULL DEFAULT 'UTC'")
+	d.DB.Exec("ALTER TABLE users ADD COLUMN current_streak INTEGER NOT NULL DEFAULT 0")
+	d.DB.Exec("ALTER TABLE users ADD COLUMN longest_streak INTEGER NOT NULL DEFAULT 0")
+	d.DB.Exec("ALTER TABLE users ADD COLUMN last_post_date TEXT")
+	d.DB.Exec("ALTER TABLE users ADD COLUMN last_daily_moment_date TEXT")
+	d.DB.Exec("ALTER TABLE beer_posts ADD COLUMN visibility TEXT NOT NULL DEFAULT 'PUBLIC' CHECK(visibility IN ('PUBLIC', 'FRIENDS', 'PRIVATE'))")
+	d.DB.Exec("ALTER TABLE beer_posts ADD COLUMN beer_name TEXT")
+	d.DB.Exec("ALTER TABLE beer_posts ADD COLUMN brewery TEXT")
+	d.DB.Exec("ALTER TABLE beer_posts ADD COLUMN beer_style TEXT")
+	d.DB.Exec("ALTER TABLE beer_posts ADD COLUMN abv REAL")
+	d.DB.Exec("ALTER TABLE beer_posts ADD COLUMN serving_type TEXT CHECK(serving_type IN ('DRAFT', 'BOTTLE', 'CAN'))")
+	d.DB.Exec("ALTER TABLE beer_posts ADD COLUMN rating INTEGER CHECK(rating BETWEEN 1 AND 5)")
+	d.DB.Exec("ALTER TABLE beer_posts ADD COLUMN beer_id TEXT REFERENCES beers(id)")
+	d.DB.Exec("CREATE INDEX IF NOT EXISTS idx_beer_posts_beer_id ON beer_posts(beer_id)")
+	d.DB.Exec("ALTER TABLE beer_posts ADD COLUMN latitude REAL CHECK(latitude BETWEEN -90 AND 90)")
+	d.DB.Exec("ALTER TABLE beer_posts ADD COLUMN longitude REAL CHECK(longitude BETWEEN -180 AND 180)")
+	d.DB.Exec("ALTER TABLE beer_posts ADD COLUMN location_accuracy REAL")
+	d.DB.Exec("CREATE INDEX IF NOT EXISTS idx_beer_posts_lat_lng ON beer_posts(latitude, longitude)")
+	d.DB.Exec("ALTER TABLE beer_posts ADD COLUMN venue_id TEXT REFERENCES venues(id)")
+	d.DB.Exec("CREATE INDEX IF NOT EXISTS idx_beer_posts_venue_id ON beer_posts(venue_id)")
+	d.DB.Exec("CREATE INDEX IF NOT EXISTS idx_beer_posts_venue_user ON beer_posts(venue_id, user_id)")
+	d.DB.Exec("ALTER TABLE taste_score_events ADD COLUMN outbox_id INTEGER")
+	d.DB.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_taste_score_events_outbox_id ON taste_score_events(outbox_id)")
+
+	// Rows written before these timestamps were stored in UTC
+	if err := d.normalizeToUTC("taste_score_events", "created_at"); err != nil {
+		return err
+	}
+	if err := d.normalizeToUTC("votes", "created_at", "updated_at"); err != nil {
+		return err
+	}
+	if err := d.normalizeToUTC("reactions", "created_at"); err != nil {
+		return err
+	}
+	if err := d.normalizeToUTC("realmojis", "created_at"); err != nil {
+		return err
+	}
+
+	if err := d.backfillStreaks(time.Now()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// normalizeToUTC rewrites timestamps stored with another offset to UTC, in the
+// format the SQLite driver writes, so that they compare correctly as strings
+// against UTC bounds. Sub-millisecond precision of rewritten values is lost.
+func (d *Database) normalizeToUTC(table string, columns ...string) error {
+	for _, column := range columns {
+		query := fmt.Sprintf(`
+			UPDATE %[1]s
+			SET %[2]s = rtrim(rtrim(strftime('%%Y-%%m-%%d %%H:%%M:%%f', %[2]s), '0'), '.') || '+00:00'
+			WHERE typeof(%[2]s) = 'text'
+			  AND %[2]s NOT LIKE '%%+00:00'
+			  AND strftime('%%s', %[2]s) IS NOT NULL`, table, column)
+		if _, err := d.DB.Exec(query); err != nil {
+			return fmt.Errorf("failed to normalize %s.%s to UTC: %w", table, column, err)
+		}
+	}
+	return nil
+}
+
+// backfillStreaks computes the posting streaks of users who posted before
+// streaks were tracked. Any post sets last_post_date, so users with posts but
+// without one have never had their streak computed.
+func (d *Database) backfillStreaks(now time.Time) error {
+	rows, err := d.DB.Query(`
+		SELECT u.id, u.time_zone, bp.timestamp
+		FROM users u
+		JOIN beer_posts bp ON bp.user_id = u.id
+		WHERE u.last_post_date IS NULL
+		ORDER BY u.id, bp.timestamp ASC
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to get posts for streak backfill: %w", err)
+	}
+
+	type userPosts struct {
+		id       string
+		timeZone string
+		times    []time.Time
+	}
+	var users []*userPosts
+	for rows.Next() {
+		var id, timeZone string
+		var timestamp time.Time
+		if err := rows.Scan(&id, &timeZone, &timestamp); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan post for streak backfill: %w", err)
+		}
+		if len(users) == 0 || users[len(users)-1].id != id {
+			users = append(users, &userPosts{id: id, timeZone: timeZone})
+		}
+		users[len(users)-1].times = append(users[len(users)-1].times, timestamp)
+	}
+	rows.Close()
+	if len(users) == 0 {
+		return nil
+	}
+
+	tx, err := d.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, user := range users {
+		userStreak := models.UserStreak{UserID: user.id, TimeZone: user.timeZone}
+		for _, t := range user.times {
+			streak.Advance(&userStreak, t)
+		}
+		// The nightly job would already have reset a streak with a missed day
+		if streak.Broken(userStreak, now) {
+			userStreak.CurrentStreak = 0
+		}
+
+		_, err = tx.Exec(
+			`UPDATE users SET current_streak = ?, longest_streak = ?, last_post_date = ? WHERE id = ?`,
+			userStreak.CurrentStreak, userStreak.LongestStreak, userStreak.LastPostDate, user.id,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to backfill streak: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit streak backfill: %w", err)
+	}
+	log.Printf("Backfilled posting streaks for %d users", len(users))
 	return nil
 }
 
@@ -0,0 +1,130 @@
+// Package events is an in-process publish/subscribe bus for things that
+// happened in the service layer, such as a new post or vote. Side effects
+// like notifications subscribe to it instead of being called from the write
+// paths directly.
+package events
+
+import (
+	"log"
+	"sync"
+)
+
+// asyncQueueSize is how many events an async subscriber may fall behind
+// before further events are dropped.
+const asyncQueueSize = 1000
+
+// Event is a domain event published on the bus.
+type Event interface {
+	// Name identifies the kind of event, e.g. in logs.
+	Name() string
+}
+
+// Handler receives published events.
+type Handler func(event Event)
+
+// DeliveryMode controls how a subscriber receives events.
+type DeliveryMode int
+
+const (
+	// Sync handlers run on the publisher's goroutine before Publish returns,
+	// so their effects are visible in the response of the request.
+	Sync DeliveryMode = iota
+	// Async handlers run on a goroutine of their own, in publish order, and
+	// never slow down the publisher.
+	Async
+)
+
+type subscriber struct {
+	name    string
+	handler Handler
+	queue   chan Event
+	// closed is set, under the bus lock, once the queue is closed
+	closed bool
+}
+
+// Bus delivers every published event to all subscribers.
+type Bus struct {
+	mu          sync.RWMutex
+	nextID      int
+	subscribers map[int]*subscriber
+}
+
+func NewBus() *Bus {
+	return &Bus{subscribers: map[int]*subscriber{}}
+}
+
+// Subscribe registers the handler and returns a function that removes it.
+// The name is used in logs.
+func (b *Bus) Subscribe(name string, mode DeliveryMode, handler Handler) (unsubscribe func()) {
+	sub := &subscriber{name: name, handler: handler}
+	if mode == Async {
+		sub.queue = make(chan Event, asyncQueueSize)
+		go func() {
+			for event := range sub.queue {
+				sub.deliver(event)
+			}
+		}()
+	}
+
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	b.subscribers[id] = sub
+	b.mu.Unlock()
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[id]; ok {
+			delete(b.subscribers, id)
+			if sub.queue != nil {
+				sub.closed = true
+				close(sub.queue)
+			}
+		}
+	}
+}
+
+// Publish passes the event to each subscriber, in no particular order. Sync
+// subscribers are called in turn; async ones only have the event queued.
+// Handlers may publish further events.
+func (b *Bus) Publish(event Event) {
+	b.mu.RLock()
+	subscribers := make([]*subscriber, 0, len(b.subscribers))
+	for _, sub := range b.subscribers {
+		subscribers = append(subscribers, sub)
+	}
+	b.mu.RUnlock()
+
+	for _, sub := range subscribers {
+		if sub.queue == nil {
+			sub.deliver(event)
+		} else {
+			b.enqueue(sub, event)
+		}
+	}
+}
+
+func (b *Bus) enqueue(sub *subscriber, event Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if sub.closed {
+		return
+	}
+	select {
+	case sub.queue <- event:
+	default:
+		log.Printf("[EventBus] ERROR: %s is falling behind, dropping %s event", sub.name, event.Name())
+	}
+}
+
+// deliver calls the handler, logging a panic instead of letting one
+// subscriber take down the publisher or the other subscribers.
+func (s *subscriber) deliver(event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[EventBus] ERROR: %s panicked handling %s: %v", s.name, event.Name(), r)
+		}
+	}()
+	s.handler(event)
+}
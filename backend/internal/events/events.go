@@ -0,0 +1,62 @@
+package events
+
+import "github.com/batku/beerreal/internal/models"
+
+// PostCreated is published after a post is stored.
+type PostCreated struct {
+	Post models.BeerPost
+}
+
+func (PostCreated) Name() string { return "PostCreated" }
+
+// VoteCast is published after a vote is added, changed or taken back, with
+// the post's new vote counts. It is also stored in the outbox with the vote,
+// so the JSON form is what outbox handlers receive.
+type VoteCast struct {
+	VoteID   string          `json:"voteId"`
+	PostID   string          `json:"postId"`
+	AuthorID string          `json:"authorId"`
+	VoterID  string          `json:"voterId"`
+	VoteType models.VoteType `json:"voteType"`
+	// Previous is the voter's earlier vote on the post, empty if there was
+	// none
+	Previous models.VoteType `json:"previous,omitempty"`
+	Removed  bool            `json:"removed"`
+	// The vote counts are only known once the vote is saved, so they are not
+	// part of the outbox message
+	Upvotes   int `json:"-"`
+	Downvotes int `json:"-"`
+}
+
+func (VoteCast) Name() string { return "VoteCast" }
+
+// CommentAdded is published after a comment is stored.
+type CommentAdded struct {
+	PostAuthorID string
+	Comment      models.Comment
+}
+
+func (CommentAdded) Name() string { return "CommentAdded" }
+
+// FriendRequested is published after a user sends a friend request.
+type FriendRequested struct {
+	FromUserID string
+	ToUserID   string
+}
+
+func (FriendRequested) Name() string { return "FriendRequested" }
+
+// FriendAccepted is published after a user accepts a friend request.
+type FriendAccepted struct {
+	AccepterID  string
+	RequesterID string
+}
+
+func (FriendAccepted) Name() string { return "FriendAccepted" }
+
+// UserUpdated is published after a user changes their profile.
+type UserUpdated struct {
+	User models.User
+}
+
+func (UserUpdated) Name() string { return "UserUpdated" }
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/batku/beerreal/internal/middleware"
+	"github.com/batku/beerreal/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type AchievementHandler struct {
+	service *service.AchievementService
+}
+
+func NewAchievementHandler(service *service.AchievementService) *AchievementHandler {
+	return &AchievementHandler{service: service}
+}
+
+// GetMyAchievements godoc
+// @Summary List the current user's achievements
+// @Description List every achievement with the user's progress and when it was unlocked
+// @Tags achievements
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} models.GetAchievementsResponse
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/me/achievements [get]
+func (h *AchievementHandler) GetMyAchievements(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	response, err := h.service.GetAchievements(userID)
+	if err != nil {
+		log.Printf("[AchievementHandler] GetMyAchievements error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get achievements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RegisterRoutes registers all achievement routes
+func (h *AchievementHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	router.GET("/me/achievements", authMiddleware, h.GetMyAchievements)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/batku/beerreal/internal/middleware"
+	"github.com/batku/beerreal/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type CatalogHandler struct {
+	service *service.CatalogService
+}
+
+func NewCatalogHandler(service *service.CatalogService) *CatalogHandler {
+	return &CatalogHandler{service: service}
+}
+
+// SearchBeers godoc
+// @Summary Autocomplete beers from the catalog
+// @Description Match the query against the start of catalog beer names and aliases
+// @Tags beers
+// @Produce json
+// @Param q query string true "Search text"
+// @Param limit query int false "Maximum number of results" default(10)
+// @Success 200 {object} models.SearchBeersResponse
+// @Failure 500 {object} map[string]string
+// @Router /api/beers/search [get]
+func (h *CatalogHandler) SearchBeers(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	response, err := h.service.SearchBeers(c.Query("q"), limit)
+	if err != nil {
+		log.Printf("[CatalogHandler] SearchBeers error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search beers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetBeer godoc
+// @Summary Get a catalog beer
+// @Description Get a beer with aggregate stats and a paginated feed of posts about it
+// @Tags beers
+// @Produce json
+// @Param id path string true "Beer ID"
+// @Param page query int false "Page number" default(1)
+// @Param pageSize query int false "Page size" default(20)
+// @Success 200 {object} models.GetBeerResponse
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/beers/{id} [get]
+func (h *CatalogHandler) GetBeer(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	userID, _ := middleware.GetUserID(c)
+
+	response, err := h.service.GetBeer(c.Param("id"), userID, page, pageSize)
+	if err != nil {
+		if errors.Is(err, service.ErrBeerNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Beer not found"})
+			return
+		}
+		log.Printf("[CatalogHandler] GetBeer error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get beer"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RegisterRoutes registers all catalog routes
+func (h *CatalogHandler) RegisterRoutes(router *gin.RouterGroup, optionalAuthMiddleware gin.HandlerFunc) {
+	beers := router.Group("/beers")
+	{
+		beers.GET("/search", optionalAuthMiddleware, h.SearchBeers)
+		beers.GET("/:id", optionalAuthMiddleware, h.GetBeer)
+	}
+}
@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/batku/beerreal/internal/middleware"
+	"github.com/batku/beerreal/internal/models"
+	"github.com/batku/beerreal/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	service *service.NotificationService
+}
+
+func NewNotificationHandler(service *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{service: service}
+}
+
+// GetNotifications godoc
+// @Summary List notifications
+// @Description Get the current user's notifications, newest first, with cursor pagination
+// @Tags notifications
+// @Security BearerAuth
+// @Produce json
+// @Param cursor query string false "nextCursor from the previous page"
+// @Param limit query int false "Page size (max 100)" default(20)
+// @Success 200 {object} models.GetNotificationsResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/notifications [get]
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	response, err := h.service.GetNotifications(userID, c.Query("cursor"), limit)
+	if err != nil {
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Message, "field": validationErr.Field})
+			return
+		}
+		log.Printf("[NotificationHandler] GetNotifications error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// MarkRead godoc
+// @Summary Mark notifications as read
+// @Description Mark the listed notifications as read, or all notifications if no ids are given
+// @Tags notifications
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.MarkNotificationsReadRequest false "Notification IDs"
+// @Success 200 {object} models.MarkNotificationsReadResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/notifications/read [post]
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.MarkNotificationsReadRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	response, err := h.service.MarkRead(userID, &req)
+	if err != nil {
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Message, "field": validationErr.Field})
+			return
+		}
+		log.Printf("[NotificationHandler] MarkRead error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark notifications as read"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RegisterDevice godoc
+// @Summary Register a device for push notifications
+// @Description Store the device's FCM token so notifications are also sent as push messages
+// @Tags notifications
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param device body models.RegisterDeviceRequest true "Device token"
+// @Success 200 {object} models.Device
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/me/devices [post]
+func (h *NotificationHandler) RegisterDevice(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.RegisterDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	device, err := h.service.RegisterDevice(userID, &req)
+	if err != nil {
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Message, "field": validationErr.Field})
+			return
+		}
+		log.Printf("[NotificationHandler] RegisterDevice error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register device"})
+		return
+	}
+
+	c.JSON(http.StatusOK, device)
+}
+
+// UnregisterDevice godoc
+// @Summary Unregister a device
+// @Description Stop sending push notifications to the device token
+// @Tags notifications
+// @Security BearerAuth
+// @Accept json
+// @Param device body models.UnregisterDeviceRequest true "Device token"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/me/devices [delete]
+func (h *NotificationHandler) UnregisterDevice(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.UnregisterDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.UnregisterDevice(userID, req.Token); err != nil {
+		log.Printf("[NotificationHandler] UnregisterDevice error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unregister device"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetSettings godoc
+// @Summary Get notification settings
+// @Description Get which notifications the current user receives and their quiet hours
+// @Tags notifications
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} models.NotificationSettings
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/me/notification-settings [get]
+func (h *NotificationHandler) GetSettings(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	settings, err := h.service.GetSettings(userID)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		log.Printf("[NotificationHandler] GetSettings error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get notification settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettings godoc
+// @Summary Update notification settings
+// @Description Turn notification types on or off and set quiet hours; only the fields sent are changed
+// @Tags notifications
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param settings body models.UpdateNotificationSettingsRequest true "Settings to change"
+// @Success 200 {object} models.NotificationSettings
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/me/notification-settings [patch]
+func (h *NotificationHandler) UpdateSettings(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.UpdateNotificationSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.service.UpdateSettings(userID, &req)
+	if err != nil {
+		var validationErr *service.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Message, "field": validationErr.Field})
+		case errors.Is(err, service.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		default:
+			log.Printf("[NotificationHandler] UpdateSettings error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification settings"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// RegisterRoutes registers all notification routes
+func (h *NotificationHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	notifications := router.Group("/notifications")
+	{
+		notifications.GET("", authMiddleware, h.GetNotifications)
+		notifications.POST("/read", authMiddleware, h.MarkRead)
+	}
+	router.POST("/me/devices", authMiddleware, h.RegisterDevice)
+	router.DELETE("/me/devices", authMiddleware, h.UnregisterDevice)
+	router.GET("/me/notification-settings", authMiddleware, h.GetSettings)
+	router.PATCH("/me/notification-settings", authMiddleware, h.UpdateSettings)
+}
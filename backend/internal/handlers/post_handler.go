@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -53,6 +54,11 @@ func (h *PostHandler) CreatePost(c *gin.Context) {
 	post, err := h.service.CreatePost(userID, &req)
 	if err != nil {
 		log.Printf("[CreatePost] ERROR: Failed to create post: %v", err)
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Message, "field": validationErr.Field})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -128,6 +134,48 @@ func (h *PostHandler) GetUserPosts(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// GetNearbyPosts godoc
+// @Summary Get posts near a location
+// @Description Get posts within a radius of a point, newest first, for the map screen
+// @Tags posts
+// @Produce json
+// @Param lat query number true "Latitude"
+// @Param lng query number true "Longitude"
+// @Param radiusKm query number false "Search radius in kilometers (max 50)" default(5)
+// @Param hours query int false "Only include posts from the last N hours, 0 for all time" default(24)
+// @Param limit query int false "Maximum number of posts (max 500)" default(100)
+// @Success 200 {object} models.GetNearbyPostsResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/posts/nearby [get]
+func (h *PostHandler) GetNearbyPosts(c *gin.Context) {
+	lat, latErr := strconv.ParseFloat(c.Query("lat"), 64)
+	lng, lngErr := strconv.ParseFloat(c.Query("lng"), 64)
+	if latErr != nil || lngErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lat and lng query parameters are required"})
+		return
+	}
+	radiusKm, _ := strconv.ParseFloat(c.DefaultQuery("radiusKm", "5"), 64)
+	hours, _ := strconv.Atoi(c.DefaultQuery("hours", "24"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+
+	userID, _ := middleware.GetUserID(c)
+
+	response, err := h.service.GetNearbyPosts(userID, lat, lng, radiusKm, hours, limit)
+	if err != nil {
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Message, "field": validationErr.Field})
+			return
+		}
+		log.Printf("[GetNearbyPosts] ERROR: Failed to get nearby posts: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
 // GetPost godoc
 // @Summary Get a single beer post
 // @Description Get a beer post by ID
@@ -175,6 +223,7 @@ func (h *PostHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin
 	{
 		// Public routes (no auth required, but optional auth for user context)
 		posts.GET("", optionalAuthMiddleware, h.GetPosts)
+		posts.GET("/nearby", optionalAuthMiddleware, h.GetNearbyPosts)
 		posts.GET("/:id", optionalAuthMiddleware, h.GetPost)
 
 		// Protected routes (auth required)
@@ -195,6 +244,8 @@ func (h *PostHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin
 // @Success 200 {object} models.VoteResponse
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/posts/vote [post]
 func (h *PostHandler) VotePost(c *gin.Context) {
@@ -212,6 +263,14 @@ func (h *PostHandler) VotePost(c *gin.Context) {
 
 	response, err := h.service.VotePost(userID, &req)
 	if err != nil {
+		if errors.Is(err, service.ErrBlocked) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrPostNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -230,6 +289,8 @@ func (h *PostHandler) VotePost(c *gin.Context) {
 // @Success 201 {object} models.Comment
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/posts/comment [post]
 func (h *PostHandler) AddComment(c *gin.Context) {
@@ -247,6 +308,14 @@ func (h *PostHandler) AddComment(c *gin.Context) {
 
 	comment, err := h.service.AddComment(userID, &req)
 	if err != nil {
+		if errors.Is(err, service.ErrBlocked) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrPostNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
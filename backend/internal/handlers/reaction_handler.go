@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/batku/beerreal/internal/middleware"
+	"github.com/batku/beerreal/internal/models"
+	"github.com/batku/beerreal/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ReactionHandler struct {
+	service *service.ReactionService
+}
+
+func NewReactionHandler(service *service.ReactionService) *ReactionHandler {
+	return &ReactionHandler{service: service}
+}
+
+// GetReactions godoc
+// @Summary List reactions to a post
+// @Description List who reacted to a post, newest first
+// @Tags reactions
+// @Produce json
+// @Param id path string true "Post ID"
+// @Param type query string false "Only this reaction type"
+// @Param page query int false "Page number" default(1)
+// @Param pageSize query int false "Page size" default(20)
+// @Success 200 {object} models.GetReactionsResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/posts/{id}/reactions [get]
+func (h *ReactionHandler) GetReactions(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	reactionType := models.ReactionType(strings.ToUpper(c.Query("type")))
+
+	response, err := h.service.GetReactions(c.Param("id"), userID, reactionType, page, pageSize)
+	if err != nil {
+		h.respondError(c, "GetReactions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// AddReaction godoc
+// @Summary React to a post
+// @Description Add an emoji reaction to a post; adding one twice has no effect
+// @Tags reactions
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Post ID"
+// @Param type path string true "Reaction type: CHEERS, BEER, SICK, LAUGH, LOVE or FIRE"
+// @Success 200 {object} models.PostReactionsResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/posts/{id}/reactions/{type} [put]
+func (h *ReactionHandler) AddReaction(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	reactionType := models.ReactionType(strings.ToUpper(c.Param("type")))
+	response, err := h.service.AddReaction(userID, c.Param("id"), reactionType)
+	if err != nil {
+		h.respondError(c, "AddReaction", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RemoveReaction godoc
+// @Summary Remove a reaction
+// @Description Take back the caller's reaction of the given type
+// @Tags reactions
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Post ID"
+// @Param type path string true "Reaction type"
+// @Success 200 {object} models.PostReactionsResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/posts/{id}/reactions/{type} [delete]
+func (h *ReactionHandler) RemoveReaction(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	reactionType := models.ReactionType(strings.ToUpper(c.Param("type")))
+	response, err := h.service.RemoveReaction(userID, c.Param("id"), reactionType)
+	if err != nil {
+		h.respondError(c, "RemoveReaction", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetRealMojis godoc
+// @Summary List RealMojis on a post
+// @Description List the selfie reactions to a post, newest first
+// @Tags reactions
+// @Produce json
+// @Param id path string true "Post ID"
+// @Param page query int false "Page number" default(1)
+// @Param pageSize query int false "Page size" default(20)
+// @Success 200 {object} models.GetRealMojisResponse
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/posts/{id}/realmojis [get]
+func (h *ReactionHandler) GetRealMojis(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+
+	response, err := h.service.GetRealMojis(c.Param("id"), userID, page, pageSize)
+	if err != nil {
+		h.respondError(c, "GetRealMojis", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// AddRealMoji godoc
+// @Summary React to a post with a selfie
+// @Description Upload a RealMoji for a post, replacing the caller's earlier one
+// @Tags reactions
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Post ID"
+// @Param realMoji body models.CreateRealMojiRequest true "Selfie as a base64 data URI"
+// @Success 201 {object} models.RealMoji
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/posts/{id}/realmojis [post]
+func (h *ReactionHandler) AddRealMoji(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.CreateRealMojiRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	realMoji, err := h.service.AddRealMoji(userID, c.Param("id"), &req)
+	if err != nil {
+		h.respondError(c, "AddRealMoji", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, realMoji)
+}
+
+// DeleteRealMoji godoc
+// @Summary Delete a RealMoji
+// @Description Delete a RealMoji; allowed for its owner and for the post's author
+// @Tags reactions
+// @Security BearerAuth
+// @Param id path string true "Post ID"
+// @Param realMojiId path string true "RealMoji ID"
+// @Success 204
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/posts/{id}/realmojis/{realMojiId} [delete]
+func (h *ReactionHandler) DeleteRealMoji(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := h.service.DeleteRealMoji(userID, c.Param("id"), c.Param("realMojiId")); err != nil {
+		h.respondError(c, "DeleteRealMoji", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ReactionHandler) respondError(c *gin.Context, operation string, err error) {
+	var validationErr *service.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Message, "field": validationErr.Field})
+	case errors.Is(err, service.ErrBlocked), errors.Is(err, service.ErrNotRealMojiOwner):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrPostNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+	case errors.Is(err, service.ErrRealMojiNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "RealMoji not found"})
+	default:
+		log.Printf("[ReactionHandler] %s error: %v", operation, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process reaction"})
+	}
+}
+
+// RegisterRoutes registers all reaction routes
+func (h *ReactionHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc, optionalAuthMiddleware gin.HandlerFunc) {
+	router.GET("/posts/:id/reactions", optionalAuthMiddleware, h.GetReactions)
+	router.PUT("/posts/:id/reactions/:type", authMiddleware, h.AddReaction)
+	router.DELETE("/posts/:id/reactions/:type", authMiddleware, h.RemoveReaction)
+	router.GET("/posts/:id/realmojis", optionalAuthMiddleware, h.GetRealMojis)
+	router.POST("/posts/:id/realmojis", authMiddleware, h.AddRealMoji)
+	router.DELETE("/posts/:id/realmojis/:realMojiId", authMiddleware, h.DeleteRealMoji)
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/batku/beerreal/internal/middleware"
+	"github.com/batku/beerreal/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// streamHeartbeatInterval keeps idle connections from being closed by
+// proxies.
+const streamHeartbeatInterval = 25 * time.Second
+
+type StreamHandler struct {
+	service *service.StreamService
+}
+
+func NewStreamHandler(service *service.StreamService) *StreamHandler {
+	return &StreamHandler{service: service}
+}
+
+// Stream godoc
+// @Summary Real-time updates
+// @Description Server-sent events with new posts from friends (post), vote counts (votes) and new comments (comment) for watched posts and the caller's own posts. The stream ends with a reauth event when the token expires.
+// @Tags stream
+// @Security BearerAuth
+// @Produce text/event-stream
+// @Param posts query string false "Comma-separated IDs of posts to watch (max 100)"
+// @Param access_token query string false "ID token, for clients that cannot set the Authorization header"
+// @Success 200 {string} string "event stream"
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/stream [get]
+func (h *StreamHandler) Stream(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var postIDs []string
+	if posts := c.Query("posts"); posts != "" {
+		postIDs = strings.Split(posts, ",")
+	}
+
+	conn, err := h.service.Connect(userID, postIDs)
+	if err != nil {
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Message, "field": validationErr.Field})
+			return
+		}
+		log.Printf("[StreamHandler] Stream error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open stream"})
+		return
+	}
+	defer h.service.Disconnect(conn)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	var expired <-chan time.Time
+	if expiresAt, ok := middleware.GetTokenExpiry(c); ok {
+		timer := time.NewTimer(time.Until(expiresAt))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	c.SSEvent("ready", gin.H{"posts": conn.PostIDs()})
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-conn.Events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(string(event.Type), event.Data)
+			return true
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": ping\n\n")
+			return err == nil
+		case <-expired:
+			c.SSEvent("reauth", gin.H{"error": "Token expired"})
+			return false
+		}
+	})
+}
+
+// RegisterRoutes registers the stream route
+func (h *StreamHandler) RegisterRoutes(router *gin.RouterGroup, streamAuthMiddleware gin.HandlerFunc) {
+	router.GET("/stream", streamAuthMiddleware, h.Stream)
+}
@@ -1,9 +1,14 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/batku/beerreal/internal/middleware"
 	"github.com/batku/beerreal/internal/models"
 	"github.com/batku/beerreal/internal/service"
 	"github.com/gin-gonic/gin"
@@ -56,15 +61,463 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 
 	user, err := h.service.UpdateUser(userID.(string), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		var validationErr *service.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Message, "field": validationErr.Field})
+		case errors.Is(err, service.ErrUsernameTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// DeleteMe godoc
+// @Summary Delete the current user's account
+// @Description Permanently delete the user together with their posts, comments and votes
+// @Tags users
+// @Security BearerAuth
+// @Success 204
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/me [delete]
+func (h *UserHandler) DeleteMe(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := h.service.DeleteUser(userID); err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		log.Printf("[UserHandler] DeleteMe error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+		return
+	}
+
+	log.Printf("[UserHandler] Deleted user %s", userID)
+	c.Status(http.StatusNoContent)
+}
+
+// ExportMe godoc
+// @Summary Export the current user's data
+// @Description Download a ZIP with a JSON dump of the user's data and their original images
+// @Tags users
+// @Produce application/zip
+// @Security BearerAuth
+// @Success 200 {file} file
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/me/export [get]
+func (h *UserHandler) ExportMe(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	data, err := h.service.ExportUserData(userID)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		log.Printf("[UserHandler] ExportMe error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export user data"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="beerreal-export-%s.zip"`, userID))
+	c.Data(http.StatusOK, "application/zip", data)
+}
+
+// GetUser godoc
+// @Summary Get a user's public profile
+// @Description Get the public profile of a user by ID (email is never included)
+// @Tags users
+// @Produce json
+// @Param userId path string true "User ID"
+// @Success 200 {object} models.PublicUser
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/users/{userId} [get]
+func (h *UserHandler) GetUser(c *gin.Context) {
+	targetUserID := c.Param("userId")
+
+	user, err := h.service.GetPublicUser(targetUserID)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		log.Printf("[UserHandler] GetUser error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
 		return
 	}
 
 	c.JSON(http.StatusOK, user)
 }
 
-func (h *UserHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+// SearchUsers godoc
+// @Summary Search users by username
+// @Description Prefix search on username, paginated
+// @Tags users
+// @Produce json
+// @Param q query string true "Username prefix"
+// @Param page query int false "Page number" default(1)
+// @Param pageSize query int false "Page size" default(20)
+// @Success 200 {object} models.SearchUsersResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/users/search [get]
+func (h *UserHandler) SearchUsers(c *gin.Context) {
+	query := c.Query("q")
+	if strings.TrimSpace(query) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter q is required"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+
+	response, err := h.service.SearchUsers(query, page, pageSize)
+	if err != nil {
+		log.Printf("[UserHandler] SearchUsers error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetTasteScoreHistory godoc
+// @Summary Get the current user's taste score history
+// @Description Daily taste score changes and per-post contributions over the last N days
+// @Tags users
+// @Security BearerAuth
+// @Produce json
+// @Param days query int false "Number of days (max 365)" default(30)
+// @Param tz query string false "IANA time zone for day boundaries" default(UTC)
+// @Success 200 {object} models.TasteScoreHistoryResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/me/taste-score/history [get]
+func (h *UserHandler) GetTasteScoreHistory(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "30"))
+
+	response, err := h.service.GetTasteScoreHistory(userID, days, c.Query("tz"))
+	if err != nil {
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Message, "field": validationErr.Field})
+			return
+		}
+		if errors.Is(err, service.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		log.Printf("[UserHandler] GetTasteScoreHistory error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get taste score history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetLeaderboard godoc
+// @Summary Get the taste score leaderboard
+// @Description Rank users by taste score, globally or among the caller's friends, over all time or a recent window computed from votes
+// @Tags users
+// @Produce json
+// @Param scope query string false "global or friends (requires authentication)" default(global)
+// @Param period query string false "all, week or month" default(all)
+// @Param limit query int false "Maximum number of entries (max 100)" default(50)
+// @Success 200 {object} models.GetLeaderboardResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/leaderboard [get]
+func (h *UserHandler) GetLeaderboard(c *gin.Context) {
+	scope := models.LeaderboardScope(c.Query("scope"))
+	period := models.LeaderboardPeriod(c.Query("period"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	userID, exists := middleware.GetUserID(c)
+	if scope == models.LeaderboardScopeFriends && !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	response, err := h.service.GetLeaderboard(userID, scope, period, limit)
+	if err != nil {
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Message, "field": validationErr.Field})
+			return
+		}
+		log.Printf("[UserHandler] GetLeaderboard error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get leaderboard"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// restrict returns a handler that blocks or mutes the user in the path.
+// @Summary Block or mute a user
+// @Tags users
+// @Security BearerAuth
+// @Param userId path string true "User ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/users/{userId}/block [post]
+// @Router /api/users/{userId}/mute [post]
+func (h *UserHandler) restrict(restrictionType models.RestrictionType) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := middleware.GetUserID(c)
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+
+		err := h.service.RestrictUser(userID, c.Param("userId"), restrictionType)
+		if err != nil {
+			var validationErr *service.ValidationError
+			switch {
+			case errors.As(err, &validationErr):
+				c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Message})
+			case errors.Is(err, service.ErrUserNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			default:
+				log.Printf("[UserHandler] restrict error: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user restrictions"})
+			}
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// unrestrict returns a handler that removes a block or mute.
+// @Summary Unblock or unmute a user
+// @Tags users
+// @Security BearerAuth
+// @Param userId path string true "User ID"
+// @Success 204
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/users/{userId}/block [delete]
+// @Router /api/users/{userId}/mute [delete]
+func (h *UserHandler) unrestrict(restrictionType models.RestrictionType) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := middleware.GetUserID(c)
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+
+		if err := h.service.UnrestrictUser(userID, c.Param("userId"), restrictionType); err != nil {
+			log.Printf("[UserHandler] unrestrict error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user restrictions"})
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// listRestrictions returns a handler listing the caller's blocks or mutes.
+// @Summary List blocked or muted users
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.GetRestrictionsResponse
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/me/blocks [get]
+// @Router /api/me/mutes [get]
+func (h *UserHandler) listRestrictions(restrictionType models.RestrictionType) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := middleware.GetUserID(c)
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+
+		response, err := h.service.GetRestrictions(userID, restrictionType)
+		if err != nil {
+			log.Printf("[UserHandler] listRestrictions error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user restrictions"})
+			return
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// AddFriend godoc
+// @Summary Send or accept a friend request
+// @Description Sends a friend request, or accepts the pending request from that user
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param userId path string true "User ID"
+// @Success 200 {object} models.Friendship
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/users/{userId}/friend [post]
+func (h *UserHandler) AddFriend(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	friendship, err := h.service.AddFriend(userID, c.Param("userId"))
+	if err != nil {
+		var validationErr *service.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Message})
+		case errors.Is(err, service.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		case errors.Is(err, service.ErrBlocked):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Cannot add this user as a friend"})
+		default:
+			log.Printf("[UserHandler] AddFriend error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add friend"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, friendship)
+}
+
+// RemoveFriend godoc
+// @Summary Remove a friend or cancel a friend request
+// @Tags users
+// @Security BearerAuth
+// @Param userId path string true "User ID"
+// @Success 204
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/users/{userId}/friend [delete]
+func (h *UserHandler) RemoveFriend(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := h.service.RemoveFriend(userID, c.Param("userId")); err != nil {
+		log.Printf("[UserHandler] RemoveFriend error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove friend"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetFriends godoc
+// @Summary List the current user's friends
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.GetFriendsResponse
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/me/friends [get]
+func (h *UserHandler) GetFriends(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	response, err := h.service.GetFriends(userID)
+	if err != nil {
+		log.Printf("[UserHandler] GetFriends error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get friends"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetFriendRequests godoc
+// @Summary List incoming friend requests
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.GetFriendRequestsResponse
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/me/friend-requests [get]
+func (h *UserHandler) GetFriendRequests(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	response, err := h.service.GetFriendRequests(userID)
+	if err != nil {
+		log.Printf("[UserHandler] GetFriendRequests error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get friend requests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *UserHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc, optionalAuthMiddleware gin.HandlerFunc) {
+	// Public routes
+	router.GET("/users/search", optionalAuthMiddleware, h.SearchUsers)
+	router.GET("/users/:userId", optionalAuthMiddleware, h.GetUser)
+	router.GET("/leaderboard", optionalAuthMiddleware, h.GetLeaderboard)
+
 	// Protected routes
 	router.GET("/me", authMiddleware, h.GetMe)
+	router.PATCH("/me", authMiddleware, h.UpdateUser)
+	// PUT is kept for older app versions; it has the same PATCH semantics
 	router.PUT("/me", authMiddleware, h.UpdateUser)
+	router.DELETE("/me", authMiddleware, h.DeleteMe)
+	router.GET("/me/export", authMiddleware, h.ExportMe)
+	router.GET("/me/taste-score/history", authMiddleware, h.GetTasteScoreHistory)
+	router.GET("/me/friends", authMiddleware, h.GetFriends)
+	router.GET("/me/friend-requests", authMiddleware, h.GetFriendRequests)
+	router.POST("/users/:userId/friend", authMiddleware, h.AddFriend)
+	router.DELETE("/users/:userId/friend", authMiddleware, h.RemoveFriend)
+	router.GET("/me/blocks", authMiddleware, h.listRestrictions(models.RestrictionTypeBlock))
+	router.GET("/me/mutes", authMiddleware, h.listRestrictions(models.RestrictionTypeMute))
+	router.POST("/users/:userId/block", authMiddleware, h.restrict(models.RestrictionTypeBlock))
+	router.DELETE("/users/:userId/block", authMiddleware, h.unrestrict(models.RestrictionTypeBlock))
+	router.POST("/users/:userId/mute", authMiddleware, h.restrict(models.RestrictionTypeMute))
+	router.DELETE("/users/:userId/mute", authMiddleware, h.unrestrict(models.RestrictionTypeMute))
 }
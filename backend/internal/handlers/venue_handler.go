@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/batku/beerreal/internal/middleware"
+	"github.com/batku/beerreal/internal/models"
+	"github.com/batku/beerreal/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type VenueHandler struct {
+	service *service.VenueService
+}
+
+func NewVenueHandler(service *service.VenueService) *VenueHandler {
+	return &VenueHandler{service: service}
+}
+
+// UpsertVenue godoc
+// @Summary Register a venue
+// @Description Register a bar or other place by its Google Places ID, or return the existing venue if already known
+// @Tags venues
+// @Accept json
+// @Produce json
+// @Param venue body models.UpsertVenueRequest true "Venue details"
+// @Success 200 {object} models.Venue
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/venues [post]
+func (h *VenueHandler) UpsertVenue(c *gin.Context) {
+	var req models.UpsertVenueRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	venue, err := h.service.UpsertVenue(&req)
+	if err != nil {
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Message, "field": validationErr.Field})
+			return
+		}
+		log.Printf("[VenueHandler] UpsertVenue error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save venue"})
+		return
+	}
+
+	c.JSON(http.StatusOK, venue)
+}
+
+// GetVenue godoc
+// @Summary Get a venue
+// @Description Get a venue with aggregate stats and a paginated feed of recent posts made there
+// @Tags venues
+// @Produce json
+// @Param id path string true "Venue ID"
+// @Param page query int false "Page number" default(1)
+// @Param pageSize query int false "Page size" default(20)
+// @Success 200 {object} models.GetVenueResponse
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/venues/{id} [get]
+func (h *VenueHandler) GetVenue(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	userID, _ := middleware.GetUserID(c)
+
+	response, err := h.service.GetVenue(c.Param("id"), userID, page, pageSize)
+	if err != nil {
+		if errors.Is(err, service.ErrVenueNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Venue not found"})
+			return
+		}
+		log.Printf("[VenueHandler] GetVenue error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get venue"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetVenueLeaderboard godoc
+// @Summary Get a venue's leaderboard
+// @Description Rank users by how many posts they have made at the venue during the period
+// @Tags venues
+// @Produce json
+// @Param id path string true "Venue ID"
+// @Param period query string false "all, week or month" default(month)
+// @Param limit query int false "Maximum number of entries (max 50)" default(10)
+// @Success 200 {object} models.GetVenueLeaderboardResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/venues/{id}/leaderboard [get]
+func (h *VenueHandler) GetVenueLeaderboard(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	period := models.LeaderboardPeriod(c.Query("period"))
+	userID, _ := middleware.GetUserID(c)
+
+	response, err := h.service.GetVenueLeaderboard(c.Param("id"), userID, period, limit)
+	if err != nil {
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Message, "field": validationErr.Field})
+			return
+		}
+		if errors.Is(err, service.ErrVenueNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Venue not found"})
+			return
+		}
+		log.Printf("[VenueHandler] GetVenueLeaderboard error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get venue leaderboard"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RegisterRoutes registers all venue routes
+func (h *VenueHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc, optionalAuthMiddleware gin.HandlerFunc) {
+	venues := router.Group("/venues")
+	{
+		venues.POST("", authMiddleware, h.UpsertVenue)
+		venues.GET("/:id", optionalAuthMiddleware, h.GetVenue)
+		venues.GET("/:id/leaderboard", optionalAuthMiddleware, h.GetVenueLeaderboard)
+	}
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/batku/beerreal/internal/middleware"
+	"github.com/batku/beerreal/internal/models"
+	"github.com/batku/beerreal/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type VoteHandler struct {
+	service *service.VoteService
+}
+
+func NewVoteHandler(service *service.VoteService) *VoteHandler {
+	return &VoteHandler{service: service}
+}
+
+// GetPostVoters godoc
+// @Summary List voters on a post
+// @Description List who voted on a post, most recent vote first, with cursor pagination. Only the post's author can list voters unless VOTER_LIST_PUBLIC is set.
+// @Tags votes
+// @Produce json
+// @Param id path string true "Post ID"
+// @Param type query string false "Only UPVOTE or DOWNVOTE"
+// @Param cursor query string false "nextCursor from the previous page"
+// @Param limit query int false "Page size (max 100)" default(20)
+// @Success 200 {object} models.GetPostVotesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/posts/{id}/votes [get]
+func (h *VoteHandler) GetPostVoters(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	voteType := models.VoteType(strings.ToUpper(c.Query("type")))
+
+	response, err := h.service.GetPostVoters(c.Param("id"), userID, voteType, c.Query("cursor"), limit)
+	if err != nil {
+		h.respondError(c, "GetPostVoters", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetVoteHistory godoc
+// @Summary List my votes
+// @Description List the posts the current user voted on, most recent vote first, with cursor pagination
+// @Tags votes
+// @Security BearerAuth
+// @Produce json
+// @Param cursor query string false "nextCursor from the previous page"
+// @Param limit query int false "Page size (max 100)" default(20)
+// @Success 200 {object} models.GetVoteHistoryResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/me/votes [get]
+func (h *VoteHandler) GetVoteHistory(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	response, err := h.service.GetVoteHistory(userID, c.Query("cursor"), limit)
+	if err != nil {
+		h.respondError(c, "GetVoteHistory", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *VoteHandler) respondError(c *gin.Context, operation string, err error) {
+	var validationErr *service.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Message, "field": validationErr.Field})
+	case errors.Is(err, service.ErrVotersHidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrPostNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+	default:
+		log.Printf("[VoteHandler] %s error: %v", operation, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get votes"})
+	}
+}
+
+// RegisterRoutes registers all vote listing routes
+func (h *VoteHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc, optionalAuthMiddleware gin.HandlerFunc) {
+	router.GET("/posts/:id/votes", optionalAuthMiddleware, h.GetPostVoters)
+	router.GET("/me/votes", authMiddleware, h.GetVoteHistory)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"strings"
+	"time"
 
 	firebase "firebase.google.com/go/v4"
 	"firebase.google.com/go/v4/auth"
@@ -85,6 +86,43 @@ func (fa *FirebaseAuth) OptionalAuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// StreamAuthMiddleware authenticates long-lived streaming connections. Since
+// browser EventSource clients cannot set headers, the token may also be
+// passed as the access_token query parameter. The token's expiry is stored
+// so the connection can be closed once it is no longer valid.
+func (fa *FirebaseAuth) StreamAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.Query("access_token")
+		if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+			parts := strings.Split(authHeader, " ")
+			if len(parts) != 2 || parts[0] != "Bearer" {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
+				c.Abort()
+				return
+			}
+			token = parts[1]
+		}
+		if token == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header or access_token required"})
+			c.Abort()
+			return
+		}
+
+		decodedToken, err := fa.client.VerifyIDToken(context.Background(), token)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			c.Abort()
+			return
+		}
+
+		c.Set("userID", decodedToken.UID)
+		c.Set("email", decodedToken.Claims["email"])
+		c.Set("tokenExpiresAt", time.Unix(decodedToken.Expires, 0))
+
+		c.Next()
+	}
+}
+
 // Helper function to get user ID from context
 func GetUserID(c *gin.Context) (string, bool) {
 	userID, exists := c.Get("userID")
@@ -93,3 +131,12 @@ func GetUserID(c *gin.Context) (string, bool) {
 	}
 	return userID.(string), true
 }
+
+// GetTokenExpiry returns when the token of a stream connection expires.
+func GetTokenExpiry(c *gin.Context) (time.Time, bool) {
+	expiresAt, exists := c.Get("tokenExpiresAt")
+	if !exists {
+		return time.Time{}, false
+	}
+	return expiresAt.(time.Time), true
+}
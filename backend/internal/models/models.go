@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type User struct {
 	ID               string    `json:"id" db:"id"`
@@ -12,10 +15,51 @@ type User struct {
 	FriendsCount     int       `json:"friendsCount" db:"friends_count"`
 	JoinedDate       time.Time `json:"joinedDate" db:"joined_date"`
 	Bio              *string   `json:"bio" db:"bio"`
+	IsPrivate        bool      `json:"isPrivate" db:"is_private"`
+	// TimeZone is the IANA zone whose calendar days posting streaks follow.
+	TimeZone         string    `json:"timeZone" db:"time_zone"`
+	CurrentStreak    int       `json:"currentStreak" db:"current_streak"`
+	LongestStreak    int       `json:"longestStreak" db:"longest_streak"`
+	// LastPostDate is the local date (YYYY-MM-DD) of the user's latest post.
+	LastPostDate     *string   `json:"lastPostDate" db:"last_post_date"`
 	CreatedAt        time.Time `json:"createdAt" db:"created_at"`
 	UpdatedAt        time.Time `json:"updatedAt" db:"updated_at"`
 }
 
+// PublicUser is the projection of User that is safe to show to other users.
+type PublicUser struct {
+	ID               string    `json:"id"`
+	Username         string    `json:"username"`
+	ProfileImageData *string   `json:"profileImageData"`
+	TasteScore       int       `json:"tasteScore"`
+	TotalPosts       int       `json:"totalPosts"`
+	FriendsCount     int       `json:"friendsCount"`
+	JoinedDate       time.Time `json:"joinedDate"`
+	Bio              *string   `json:"bio"`
+	IsPrivate        bool      `json:"isPrivate"`
+	CurrentStreak    int       `json:"currentStreak"`
+	LongestStreak    int       `json:"longestStreak"`
+	// Achievements is only filled in on profile responses.
+	Achievements []UserAchievement `json:"achievements,omitempty"`
+}
+
+// ToPublic strips private fields such as the email address.
+func (u *User) ToPublic() PublicUser {
+	return PublicUser{
+		ID:               u.ID,
+		Username:         u.Username,
+		ProfileImageData: u.ProfileImageData,
+		TasteScore:       u.TasteScore,
+		TotalPosts:       u.TotalPosts,
+		FriendsCount:     u.FriendsCount,
+		JoinedDate:       u.JoinedDate,
+		Bio:              u.Bio,
+		IsPrivate:        u.IsPrivate,
+		CurrentStreak:    u.CurrentStreak,
+		LongestStreak:    u.LongestStreak,
+	}
+}
+
 type BeerPost struct {
 	ID                  string    `json:"id" db:"id"`
 	UserID              string    `json:"userId" db:"user_id"`
@@ -24,6 +68,12 @@ type BeerPost struct {
 	Caption             string    `json:"caption" db:"caption"`
 	ImageData           string    `json:"imageData" db:"image_data"`
 	Location            *string   `json:"location" db:"location"`
+	Visibility          PostVisibility `json:"visibility" db:"visibility"`
+	BeerDetails
+	GeoLocation
+	VenueID             *string   `json:"venueId" db:"venue_id"`
+	VenueName           *string   `json:"venueName" db:"venue_name"`
+	IsRegular           bool      `json:"isRegular"`
 	Timestamp           time.Time `json:"timestamp" db:"timestamp"`
 	Upvotes             int       `json:"upvotes" db:"upvotes"`
 	Downvotes           int       `json:"downvotes" db:"downvotes"`
@@ -32,6 +82,110 @@ type BeerPost struct {
 	Comments            []Comment `json:"comments"`
 	HasUserVoted        bool      `json:"hasUserVoted"`
 	UserVoteType        *VoteType `json:"userVoteType"`
+	Reactions           map[ReactionType]int `json:"reactions"`
+	UserReactions       []ReactionType       `json:"userReactions"`
+}
+
+// BeerDetails is the optional structured description of the beer in a post.
+// BeerID links the post to the beer catalog.
+type BeerDetails struct {
+	BeerID      *string      `json:"beerId" db:"beer_id"`
+	BeerName    *string      `json:"beerName" db:"beer_name"`
+	Brewery     *string      `json:"brewery" db:"brewery"`
+	BeerStyle   *string      `json:"beerStyle" db:"beer_style"`
+	ABV         *float64     `json:"abv" db:"abv"`
+	ServingType *ServingType `json:"servingType" db:"serving_type"`
+	Rating      *int         `json:"rating" db:"rating"`
+}
+
+// GeoLocation holds optional post coordinates. LocationAccuracy is a radius in
+// meters; stored coordinates are coarsened for privacy and their accuracy
+// includes that fuzzing.
+type GeoLocation struct {
+	Latitude         *float64 `json:"latitude" db:"latitude"`
+	Longitude        *float64 `json:"longitude" db:"longitude"`
+	LocationAccuracy *float64 `json:"locationAccuracy" db:"location_accuracy"`
+}
+
+// Venue is a bar or other place posts can be made from, keyed by its
+// Google Places ID.
+type Venue struct {
+	ID        string    `json:"id" db:"id"`
+	PlaceID   string    `json:"placeId" db:"place_id"`
+	Name      string    `json:"name" db:"name"`
+	Address   *string   `json:"address" db:"address"`
+	Latitude  float64   `json:"latitude" db:"latitude"`
+	Longitude float64   `json:"longitude" db:"longitude"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// Brewery is a canonical catalog entry for a brewery.
+type Brewery struct {
+	ID        string    `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	Country   *string   `json:"country" db:"country"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// Beer is a canonical catalog entry for a beer. Aliases are the normalized
+// names it can be matched by and are only used when importing.
+type Beer struct {
+	ID          string    `json:"id" db:"id"`
+	BreweryID   *string   `json:"breweryId" db:"brewery_id"`
+	BreweryName *string   `json:"breweryName" db:"brewery_name"`
+	Name        string    `json:"name" db:"name"`
+	Style       *string   `json:"style" db:"style"`
+	ABV         *float64  `json:"abv" db:"abv"`
+	Aliases     []string  `json:"-"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// CatalogEntry is one beer in a catalog import file.
+type CatalogEntry struct {
+	ID             string   `json:"id"`
+	Name           string   `json:"name"`
+	Brewery        string   `json:"brewery"`
+	BreweryCountry string   `json:"breweryCountry"`
+	Style          string   `json:"style"`
+	ABV            *float64 `json:"abv"`
+	Aliases        []string `json:"aliases"`
+}
+
+type ServingType string
+
+const (
+	ServingTypeDraft  ServingType = "DRAFT"
+	ServingTypeBottle ServingType = "BOTTLE"
+	ServingTypeCan    ServingType = "CAN"
+)
+
+func (t ServingType) IsValid() bool {
+	switch t {
+	case ServingTypeDraft, ServingTypeBottle, ServingTypeCan:
+		return true
+	}
+	return false
+}
+
+// PostVisibility controls who can see a post. On private accounts PUBLIC
+// posts are only visible to friends.
+type PostVisibility string
+
+const (
+	PostVisibilityPublic  PostVisibility = "PUBLIC"
+	PostVisibilityFriends PostVisibility = "FRIENDS"
+	PostVisibilityPrivate PostVisibility = "PRIVATE"
+)
+
+func (v PostVisibility) IsValid() bool {
+	switch v {
+	case PostVisibilityPublic, PostVisibilityFriends, PostVisibilityPrivate:
+		return true
+	}
+	return false
 }
 
 type Comment struct {
@@ -62,11 +216,156 @@ const (
 	VoteTypeDownvote VoteType = "DOWNVOTE"
 )
 
+// Voter is a user who voted on a post, as listed to the post's viewers.
+type Voter struct {
+	VoteID               string    `json:"-" db:"id"`
+	UserID               string    `json:"userId" db:"user_id"`
+	Username             string    `json:"username" db:"username"`
+	UserProfileImageData *string   `json:"userProfileImageData" db:"user_profile_image_data"`
+	VoteType             VoteType  `json:"voteType" db:"vote_type"`
+	VotedAt              time.Time `json:"votedAt" db:"updated_at"`
+}
+
+type GetPostVotesResponse struct {
+	Voters []Voter `json:"voters"`
+	// NextCursor fetches the next (older) page; null on the last page.
+	NextCursor *string `json:"nextCursor"`
+}
+
+// VotedPost is a post the user voted on, with their current vote.
+type VotedPost struct {
+	VoteID   string    `json:"-"`
+	VoteType VoteType  `json:"voteType"`
+	VotedAt  time.Time `json:"votedAt"`
+	Post     BeerPost  `json:"post"`
+}
+
+type GetVoteHistoryResponse struct {
+	Votes []VotedPost `json:"votes"`
+	// NextCursor fetches the next (older) page; null on the last page.
+	NextCursor *string `json:"nextCursor"`
+}
+
+// ReactionType is an emoji reaction to a post. Unlike votes, reactions do not
+// affect the taste score, and a user may add several types to one post.
+type ReactionType string
+
+const (
+	ReactionTypeCheers ReactionType = "CHEERS" // 🍻
+	ReactionTypeBeer   ReactionType = "BEER"   // 🍺
+	ReactionTypeSick   ReactionType = "SICK"   // 🤢
+	ReactionTypeLaugh  ReactionType = "LAUGH"  // 😂
+	ReactionTypeLove   ReactionType = "LOVE"   // 😍
+	ReactionTypeFire   ReactionType = "FIRE"   // 🔥
+)
+
+func (t ReactionType) IsValid() bool {
+	switch t {
+	case ReactionTypeCheers, ReactionTypeBeer, ReactionTypeSick, ReactionTypeLaugh, ReactionTypeLove, ReactionTypeFire:
+		return true
+	}
+	return false
+}
+
+type Reaction struct {
+	PostID               string       `json:"postId" db:"post_id"`
+	UserID               string       `json:"userId" db:"user_id"`
+	Username             string       `json:"username" db:"username"`
+	UserProfileImageData *string      `json:"userProfileImageData" db:"user_profile_image_data"`
+	Type                 ReactionType `json:"type" db:"type"`
+	CreatedAt            time.Time    `json:"createdAt" db:"created_at"`
+}
+
+type GetReactionsResponse struct {
+	Reactions  []Reaction `json:"reactions"`
+	TotalCount int        `json:"totalCount"`
+	Page       int        `json:"page"`
+	PageSize   int        `json:"pageSize"`
+}
+
+// RealMoji is a selfie reaction to a post. Each user has at most one per
+// post; uploading another replaces it.
+type RealMoji struct {
+	ID                   string    `json:"id" db:"id"`
+	PostID               string    `json:"postId" db:"post_id"`
+	UserID               string    `json:"userId" db:"user_id"`
+	Username             string    `json:"username" db:"username"`
+	UserProfileImageData *string   `json:"userProfileImageData" db:"user_profile_image_data"`
+	ImageData            string    `json:"imageData" db:"image_data"`
+	CreatedAt            time.Time `json:"createdAt" db:"created_at"`
+}
+
+type CreateRealMojiRequest struct {
+	ImageData string `json:"imageData" binding:"required"`
+}
+
+type GetRealMojisResponse struct {
+	RealMojis  []RealMoji `json:"realMojis"`
+	TotalCount int        `json:"totalCount"`
+	Page       int        `json:"page"`
+	PageSize   int        `json:"pageSize"`
+}
+
+// ExportRealMoji replaces the inline image data of a RealMoji with the name
+// of its image file in the export archive. The user's profile image is only
+// exported once, at the top level.
+type ExportRealMoji struct {
+	RealMoji
+	UserProfileImageData *string `json:"userProfileImageData,omitempty"`
+	ImageData            string  `json:"imageData,omitempty"`
+	ImageFile            string  `json:"imageFile,omitempty"`
+}
+
+// PostReactionsResponse is a post's reaction counts and the caller's own
+// reactions after adding or removing one.
+type PostReactionsResponse struct {
+	Reactions     map[ReactionType]int `json:"reactions"`
+	UserReactions []ReactionType       `json:"userReactions"`
+}
+
+// Friendship is a friend request from UserID to FriendID; once accepted the
+// relationship is symmetric.
+type Friendship struct {
+	UserID    string           `json:"userId" db:"user_id"`
+	FriendID  string           `json:"friendId" db:"friend_id"`
+	Status    FriendshipStatus `json:"status" db:"status"`
+	CreatedAt time.Time        `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time        `json:"updatedAt" db:"updated_at"`
+}
+
+type FriendshipStatus string
+
+const (
+	FriendshipStatusPending  FriendshipStatus = "PENDING"
+	FriendshipStatusAccepted FriendshipStatus = "ACCEPTED"
+)
+
+// UserRestriction records that UserID has blocked or muted TargetUserID.
+type UserRestriction struct {
+	UserID       string          `json:"userId" db:"user_id"`
+	TargetUserID string          `json:"targetUserId" db:"target_user_id"`
+	Type         RestrictionType `json:"type" db:"restriction_type"`
+	CreatedAt    time.Time       `json:"createdAt" db:"created_at"`
+}
+
+// RestrictionType is either a block (hides content and prevents interaction)
+// or a mute (only hides content).
+type RestrictionType string
+
+const (
+	RestrictionTypeBlock RestrictionType = "BLOCK"
+	RestrictionTypeMute  RestrictionType = "MUTE"
+)
+
 // Request/Response DTOs
 type CreatePostRequest struct {
-	Caption   string  `json:"caption" binding:"required"`
-	ImageData string  `json:"imageData" binding:"required"`
-	Location  *string `json:"location"`
+	Caption    string         `json:"caption" binding:"required"`
+	ImageData  string         `json:"imageData" binding:"required"`
+	Location   *string        `json:"location"`
+	Visibility PostVisibility `json:"visibility"`
+	VenueID    *string        `json:"venueId"`
+	BeerDetails
+	GeoLocation
 }
 
 type GetPostsResponse struct {
@@ -91,8 +390,491 @@ type AddCommentRequest struct {
 	Text   string `json:"text" binding:"required"`
 }
 
+// UpdateUserRequest has PATCH semantics: fields left out of the payload are
+// not changed, while an explicit null (or empty string) clears the field.
 type UpdateUserRequest struct {
-	Username         string `json:"username"`
-	ProfileImageData string `json:"profileImageData"`
-	Bio              string `json:"bio"`
+	Username         OptionalString `json:"username"`
+	ProfileImageData OptionalString `json:"profileImageData"`
+	Bio              OptionalString `json:"bio"`
+	IsPrivate        *bool          `json:"isPrivate"`
+	TimeZone         *string        `json:"timeZone"`
+}
+
+// UserStreak is the posting streak state of a user.
+type UserStreak struct {
+	UserID        string  `db:"id"`
+	TimeZone      string  `db:"time_zone"`
+	CurrentStreak int     `db:"current_streak"`
+	LongestStreak int     `db:"longest_streak"`
+	LastPostDate  *string `db:"last_post_date"`
+}
+
+// OptionalString distinguishes a field that is absent from the JSON payload
+// from one that is explicitly set to null.
+type OptionalString struct {
+	Set   bool
+	Value *string
+}
+
+func (o *OptionalString) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Value = nil
+		return nil
+	}
+
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	o.Value = &value
+	return nil
+}
+
+// IsNullOrEmpty reports whether the field was set to null or to an empty string.
+func (o OptionalString) IsNullOrEmpty() bool {
+	return o.Value == nil || *o.Value == ""
+}
+
+type SearchUsersResponse struct {
+	Users      []PublicUser `json:"users"`
+	TotalCount int          `json:"totalCount"`
+	Page       int          `json:"page"`
+	PageSize   int          `json:"pageSize"`
+}
+
+// UserDataExport is the JSON document included in a user's data export.
+type UserDataExport struct {
+	ExportedAt           time.Time             `json:"exportedAt"`
+	User                 User                  `json:"user"`
+	ProfileImageFile     string                `json:"profileImageFile,omitempty"`
+	Posts                []ExportPost          `json:"posts"`
+	Comments             []ExportComment       `json:"comments"`
+	Votes                []Vote                `json:"votes"`
+	Restrictions         []UserRestriction     `json:"restrictions"`
+	TasteScoreEvents     []TasteScoreEvent     `json:"tasteScoreEvents"`
+	Achievements         []UserAchievement     `json:"achievements"`
+	NotificationSettings *NotificationSettings `json:"notificationSettings"`
+	Reactions            []ExportReaction      `json:"reactions"`
+	RealMojis            []ExportRealMoji      `json:"realMojis"`
+}
+
+// ExportPost replaces the inline image data of a post with the name of the
+// image file stored next to the JSON document in the export archive. The
+// user's profile image is only exported once, at the top level.
+type ExportPost struct {
+	BeerPost
+	UserProfileImageData *string `json:"userProfileImageData,omitempty"`
+	ImageData            string  `json:"imageData,omitempty"`
+	ImageFile            string  `json:"imageFile,omitempty"`
+}
+
+// ExportComment is one of the user's comments in a data export, without the
+// profile image exported at the top level.
+type ExportComment struct {
+	Comment
+	UserProfileImageData *string `json:"userProfileImageData,omitempty"`
+}
+
+// ExportReaction is one of the user's reactions in a data export, without
+// the profile image exported at the top level.
+type ExportReaction struct {
+	Reaction
+	UserProfileImageData *string `json:"userProfileImageData,omitempty"`
+}
+
+type GetRestrictionsResponse struct {
+	Restrictions []UserRestriction `json:"restrictions"`
+}
+
+type GetFriendsResponse struct {
+	Friends []PublicUser `json:"friends"`
+}
+
+type GetFriendRequestsResponse struct {
+	Requests []Friendship `json:"requests"`
+}
+
+type SearchBeersResponse struct {
+	Beers []Beer `json:"beers"`
+}
+
+// BeerStats aggregates the posts about a catalog beer that the caller can see.
+type BeerStats struct {
+	PostCount     int             `json:"postCount"`
+	RatingCount   int             `json:"ratingCount"`
+	AverageRating *float64        `json:"averageRating"`
+	Upvotes       int             `json:"upvotes"`
+	Downvotes     int             `json:"downvotes"`
+	UpvoteRatio   *float64        `json:"upvoteRatio"`
+	TopLocations  []LocationCount `json:"topLocations"`
+}
+
+type LocationCount struct {
+	Location  string `json:"location"`
+	PostCount int    `json:"postCount"`
+}
+
+type GetBeerResponse struct {
+	Beer       Beer       `json:"beer"`
+	Stats      BeerStats  `json:"stats"`
+	Posts      []BeerPost `json:"posts"`
+	TotalCount int        `json:"totalCount"`
+	Page       int        `json:"page"`
+	PageSize   int        `json:"pageSize"`
+}
+
+// NearbyPost is a post with its distance from the searched point.
+type NearbyPost struct {
+	BeerPost
+	DistanceKm float64 `json:"distanceKm"`
+}
+
+type GetNearbyPostsResponse struct {
+	Posts []NearbyPost `json:"posts"`
+}
+
+type UpsertVenueRequest struct {
+	PlaceID   string   `json:"placeId" binding:"required"`
+	Name      string   `json:"name" binding:"required"`
+	Address   *string  `json:"address"`
+	Latitude  *float64 `json:"latitude" binding:"required"`
+	Longitude *float64 `json:"longitude" binding:"required"`
+}
+
+// VenueStats aggregates the posts from a venue that the caller can see.
+type VenueStats struct {
+	PostCount     int        `json:"postCount"`
+	VisitorCount  int        `json:"visitorCount"`
+	AverageRating *float64   `json:"averageRating"`
+	LastPostAt    *time.Time `json:"lastPostAt"`
+}
+
+// TasteScoreEvent records a change to a user's taste score caused by a vote
+// on one of their posts. The voter is kept for cleanup but never exposed.
+type TasteScoreEvent struct {
+	ID        string    `json:"id" db:"id"`
+	UserID    string    `json:"userId" db:"user_id"`
+	PostID    string    `json:"postId" db:"post_id"`
+	VoterID   string    `json:"-" db:"voter_id"`
+	VoteID    string    `json:"-" db:"vote_id"`
+	Delta     int       `json:"delta" db:"delta"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	// OutboxID is the outbox message the event was delivered by, so that a
+	// redelivered message is only applied once
+	OutboxID *int64 `json:"-" db:"outbox_id"`
+}
+
+// OutboxTypeVoteCast is the outbox message type of a VoteCast event, which
+// the taste score is updated from.
+const OutboxTypeVoteCast = "VoteCast"
+
+// OutboxMessage is a side effect stored in the same transaction as the change
+// that caused it, and delivered afterwards until it succeeds or is
+// dead-lettered.
+type OutboxMessage struct {
+	ID            int64     `db:"id"`
+	Type          string    `db:"type"`
+	Payload       []byte    `db:"payload"`
+	Attempts      int       `db:"attempts"`
+	LastError     *string   `db:"last_error"`
+	NextAttemptAt time.Time `db:"next_attempt_at"`
+	CreatedAt     time.Time `db:"created_at"`
+}
+
+// TasteScoreDay is the change in a user's taste score over one calendar day
+// and the score at the end of it.
+type TasteScoreDay struct {
+	Date  string `json:"date"`
+	Delta int    `json:"delta"`
+	Score int    `json:"score"`
+}
+
+// PostScoreContribution is the net taste score a post earned its author.
+type PostScoreContribution struct {
+	PostID    string  `json:"postId"`
+	Caption   string  `json:"caption"`
+	BeerName  *string `json:"beerName"`
+	Delta     int     `json:"delta"`
+	Upvotes   int     `json:"upvotes"`
+	Downvotes int     `json:"downvotes"`
+}
+
+type TasteScoreHistoryResponse struct {
+	TasteScore int                     `json:"tasteScore"`
+	TimeZone   string                  `json:"timeZone"`
+	Days       []TasteScoreDay         `json:"days"`
+	Posts      []PostScoreContribution `json:"posts"`
+}
+
+// Achievement describes a badge users unlock once a metric of their activity
+// reaches Threshold.
+type Achievement struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Threshold   int    `json:"threshold"`
+}
+
+// UserAchievement is an achievement a user has unlocked.
+type UserAchievement struct {
+	Achievement
+	UnlockedAt time.Time `json:"unlockedAt"`
+}
+
+// AchievementUnlock is a stored unlock of the achievement with the given ID.
+type AchievementUnlock struct {
+	UserID        string    `json:"userId" db:"user_id"`
+	AchievementID string    `json:"achievementId" db:"achievement_id"`
+	UnlockedAt    time.Time `json:"unlockedAt" db:"unlocked_at"`
+}
+
+// AchievementStats are the activity metrics achievement rules are checked
+// against.
+type AchievementStats struct {
+	PostCount         int
+	LongestStreakDays int
+	DistinctBreweries int
+	DistinctCountries int
+	DistinctVenues    int
+	CommentCount      int
+	VotesCast         int
+	UpvotesReceived   int
+}
+
+// AchievementProgress is an achievement with the user's progress towards it.
+type AchievementProgress struct {
+	Achievement
+	Progress   int        `json:"progress"`
+	Unlocked   bool       `json:"unlocked"`
+	UnlockedAt *time.Time `json:"unlockedAt"`
+}
+
+type GetAchievementsResponse struct {
+	Achievements []AchievementProgress `json:"achievements"`
+}
+
+// NotificationType is the kind of activity a notification reports.
+type NotificationType string
+
+const (
+	NotificationTypeVote           NotificationType = "VOTE"
+	NotificationTypeComment        NotificationType = "COMMENT"
+	NotificationTypeMention        NotificationType = "MENTION"
+	NotificationTypeFriendRequest  NotificationType = "FRIEND_REQUEST"
+	NotificationTypeFriendAccepted NotificationType = "FRIEND_ACCEPTED"
+	// NotificationTypeDailyMoment is the daily prompt to post, which is only
+	// sent as a push message and never stored in the inbox.
+	NotificationTypeDailyMoment NotificationType = "DAILY_MOMENT"
+)
+
+// DailyMomentRecipient is a user with a registered device who may get the
+// daily moment push.
+type DailyMomentRecipient struct {
+	UserID   string `db:"id"`
+	TimeZone string `db:"time_zone"`
+	// LastMomentDate is the local date of the last daily moment sent to them.
+	LastMomentDate *string `db:"last_daily_moment_date"`
+}
+
+// Notification is an entry in a user's inbox about another user's activity.
+type Notification struct {
+	ID                    string           `json:"id" db:"id"`
+	UserID                string           `json:"userId" db:"user_id"`
+	Type                  NotificationType `json:"type" db:"type"`
+	ActorID               string           `json:"actorId" db:"actor_id"`
+	ActorUsername         string           `json:"actorUsername" db:"actor_username"`
+	ActorProfileImageData *string          `json:"actorProfileImageData" db:"actor_profile_image_data"`
+	PostID                *string          `json:"postId" db:"post_id"`
+	CommentID             *string          `json:"commentId" db:"comment_id"`
+	VoteType              *VoteType        `json:"voteType" db:"vote_type"`
+	// Text is a preview of the comment or caption for comments and mentions.
+	Text      *string    `json:"text" db:"text"`
+	ReadAt    *time.Time `json:"readAt" db:"read_at"`
+	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
+}
+
+type GetNotificationsResponse struct {
+	Notifications []Notification `json:"notifications"`
+	UnreadCount   int            `json:"unreadCount"`
+	// NextCursor fetches the next (older) page; null on the last page.
+	NextCursor *string `json:"nextCursor"`
+}
+
+// MarkNotificationsReadRequest marks the listed notifications as read, or
+// every notification if IDs is empty.
+type MarkNotificationsReadRequest struct {
+	IDs []string `json:"ids"`
+}
+
+type MarkNotificationsReadResponse struct {
+	UnreadCount int `json:"unreadCount"`
+}
+
+// DevicePlatform is the operating system a push token belongs to.
+type DevicePlatform string
+
+const (
+	DevicePlatformAndroid DevicePlatform = "ANDROID"
+	DevicePlatformIOS     DevicePlatform = "IOS"
+	DevicePlatformWeb     DevicePlatform = "WEB"
+)
+
+func (p DevicePlatform) IsValid() bool {
+	switch p {
+	case DevicePlatformAndroid, DevicePlatformIOS, DevicePlatformWeb:
+		return true
+	}
+	return false
+}
+
+// Device is a push notification token registered by a user's app install.
+type Device struct {
+	Token     string         `json:"token" db:"token"`
+	UserID    string         `json:"userId" db:"user_id"`
+	Platform  DevicePlatform `json:"platform" db:"platform"`
+	CreatedAt time.Time      `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time      `json:"updatedAt" db:"updated_at"`
+}
+
+type RegisterDeviceRequest struct {
+	Token    string         `json:"token" binding:"required"`
+	Platform DevicePlatform `json:"platform" binding:"required"`
+}
+
+type UnregisterDeviceRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// StreamEventType is the SSE event name of a real-time update.
+type StreamEventType string
+
+const (
+	// StreamEventPost carries a new BeerPost from a friend
+	StreamEventPost StreamEventType = "post"
+	// StreamEventVotes carries a PostVotesUpdate
+	StreamEventVotes StreamEventType = "votes"
+	// StreamEventComment carries a new Comment
+	StreamEventComment StreamEventType = "comment"
+	// StreamEventUser carries the PublicUser of a friend who changed their
+	// profile
+	StreamEventUser StreamEventType = "user"
+)
+
+// StreamEvent is a real-time update sent to a connected client.
+type StreamEvent struct {
+	Type StreamEventType
+	Data interface{}
+}
+
+// PostVotesUpdate is the new vote counts of a post.
+type PostVotesUpdate struct {
+	PostID    string `json:"postId"`
+	Upvotes   int    `json:"upvotes"`
+	Downvotes int    `json:"downvotes"`
+}
+
+// NotificationSettings controls which notifications a user receives. Quiet
+// hours are "HH:MM" times in the user's time zone during which notifications
+// still reach the inbox but no push messages are sent.
+type NotificationSettings struct {
+	Votes           bool    `json:"votes" db:"votes"`
+	Comments        bool    `json:"comments" db:"comments"`
+	Mentions        bool    `json:"mentions" db:"mentions"`
+	FriendRequests  bool    `json:"friendRequests" db:"friend_requests"`
+	FriendAccepted  bool    `json:"friendAccepted" db:"friend_accepted"`
+	DailyMoment     bool    `json:"dailyMoment" db:"daily_moment"`
+	QuietHoursStart *string `json:"quietHoursStart" db:"quiet_hours_start"`
+	QuietHoursEnd   *string `json:"quietHoursEnd" db:"quiet_hours_end"`
+	// TimeZone is the user's profile time zone, which quiet hours use
+	TimeZone string `json:"timeZone" db:"-"`
+}
+
+// UpdateNotificationSettingsRequest changes only the fields that are set.
+// Quiet hours are set together; empty strings turn them off.
+type UpdateNotificationSettingsRequest struct {
+	Votes           *bool   `json:"votes"`
+	Comments        *bool   `json:"comments"`
+	Mentions        *bool   `json:"mentions"`
+	FriendRequests  *bool   `json:"friendRequests"`
+	FriendAccepted  *bool   `json:"friendAccepted"`
+	DailyMoment     *bool   `json:"dailyMoment"`
+	QuietHoursStart *string `json:"quietHoursStart"`
+	QuietHoursEnd   *string `json:"quietHoursEnd"`
+}
+
+// LeaderboardPeriod selects the time window a leaderboard covers.
+type LeaderboardPeriod string
+
+const (
+	LeaderboardPeriodAll   LeaderboardPeriod = "all"
+	LeaderboardPeriodWeek  LeaderboardPeriod = "week"
+	LeaderboardPeriodMonth LeaderboardPeriod = "month"
+)
+
+func (p LeaderboardPeriod) IsValid() bool {
+	switch p {
+	case LeaderboardPeriodAll, LeaderboardPeriodWeek, LeaderboardPeriodMonth:
+		return true
+	}
+	return false
+}
+
+// LeaderboardScope selects whose scores a leaderboard ranks.
+type LeaderboardScope string
+
+const (
+	LeaderboardScopeGlobal  LeaderboardScope = "global"
+	LeaderboardScopeFriends LeaderboardScope = "friends"
+)
+
+func (s LeaderboardScope) IsValid() bool {
+	return s == LeaderboardScopeGlobal || s == LeaderboardScopeFriends
+}
+
+// UserScore is a user's taste score over a leaderboard period.
+type UserScore struct {
+	UserID               string  `json:"userId"`
+	Username             string  `json:"username"`
+	UserProfileImageData *string `json:"userProfileImageData"`
+	Score                int     `json:"score"`
+}
+
+type LeaderboardEntry struct {
+	Rank int `json:"rank"`
+	UserScore
+}
+
+type GetLeaderboardResponse struct {
+	Scope   LeaderboardScope   `json:"scope"`
+	Period  LeaderboardPeriod  `json:"period"`
+	Entries []LeaderboardEntry `json:"entries"`
+}
+
+// VenueVisitor is a user's post count at a venue.
+type VenueVisitor struct {
+	UserID               string  `json:"userId"`
+	Username             string  `json:"username"`
+	UserProfileImageData *string `json:"userProfileImageData"`
+	PostCount            int     `json:"postCount"`
+}
+
+type VenueLeaderboardEntry struct {
+	Rank int `json:"rank"`
+	VenueVisitor
+}
+
+type GetVenueLeaderboardResponse struct {
+	VenueID string                  `json:"venueId"`
+	Period  LeaderboardPeriod       `json:"period"`
+	Entries []VenueLeaderboardEntry `json:"entries"`
+}
+
+type GetVenueResponse struct {
+	Venue      Venue      `json:"venue"`
+	Stats      VenueStats `json:"stats"`
+	Posts      []BeerPost `json:"posts"`
+	TotalCount int        `json:"totalCount"`
+	Page       int        `json:"page"`
+	PageSize   int        `json:"pageSize"`
 }
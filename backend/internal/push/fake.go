@@ -0,0 +1,56 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrFakeUnavailable is returned by FakeSender for injected temporary
+// failures.
+var ErrFakeUnavailable = errors.New("fake sender unavailable")
+
+// FakeSender records messages in memory instead of sending them. Tokens in
+// InvalidTokens are rejected with ErrInvalidToken, and the next FailNext sends
+// fail with ErrFakeUnavailable.
+type FakeSender struct {
+	mu            sync.Mutex
+	sent          []Message
+	attempts      int
+	InvalidTokens map[string]bool
+	FailNext      int
+}
+
+func NewFakeSender() *FakeSender {
+	return &FakeSender{InvalidTokens: map[string]bool{}}
+}
+
+func (s *FakeSender) Send(ctx context.Context, message Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.attempts++
+	if s.InvalidTokens[message.Token] {
+		return ErrInvalidToken
+	}
+	if s.FailNext > 0 {
+		s.FailNext--
+		return ErrFakeUnavailable
+	}
+	s.sent = append(s.sent, message)
+	return nil
+}
+
+// Sent returns a copy of the messages sent so far.
+func (s *FakeSender) Sent() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.sent...)
+}
+
+// Attempts returns how many times Send was called, including failed sends.
+func (s *FakeSender) Attempts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attempts
+}
@@ -0,0 +1,51 @@
+package push
+
+import (
+	"context"
+	"fmt"
+
+	firebase "firebase.google.com/go/v4"
+	"firebase.google.com/go/v4/messaging"
+	"google.golang.org/api/option"
+)
+
+// FCMSender sends messages through Firebase Cloud Messaging.
+type FCMSender struct {
+	client *messaging.Client
+}
+
+func NewFCMSender(credentialsPath string) (*FCMSender, error) {
+	ctx := context.Background()
+	opt := option.WithCredentialsFile(credentialsPath)
+
+	app, err := firebase.NewApp(ctx, nil, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := app.Messaging(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FCMSender{client: client}, nil
+}
+
+func (s *FCMSender) Send(ctx context.Context, message Message) error {
+	_, err := s.client.Send(ctx, &messaging.Message{
+		Token: message.Token,
+		Notification: &messaging.Notification{
+			Title: message.Title,
+			Body:  message.Body,
+		},
+		Data: message.Data,
+	})
+	if err == nil {
+		return nil
+	}
+	// Our payloads are well-formed, so an invalid argument means a bad token
+	if messaging.IsUnregistered(err) || messaging.IsSenderIDMismatch(err) || messaging.IsInvalidArgument(err) {
+		return fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return err
+}
@@ -0,0 +1,26 @@
+// Package push sends push notifications to user devices.
+package push
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrInvalidToken is returned by a Sender when the device token is no longer
+// valid and should be forgotten.
+var ErrInvalidToken = errors.New("invalid device token")
+
+// Message is a push notification for a single device.
+type Message struct {
+	Token string
+	Title string
+	Body  string
+	// Data is delivered to the app alongside the notification.
+	Data map[string]string
+}
+
+// Sender delivers push messages. Errors other than ErrInvalidToken are
+// treated as temporary.
+type Sender interface {
+	Send(ctx context.Context, message Message) error
+}
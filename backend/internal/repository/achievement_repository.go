@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/batku/beerreal/internal/models"
+)
+
+type AchievementRepository interface {
+	GetAchievementStats(userID string) (*models.AchievementStats, error)
+	GetUnlocks(userID string) ([]models.AchievementUnlock, error)
+	AddUnlocks(userID string, achievementIDs []string) ([]models.AchievementUnlock, error)
+}
+
+type achievementRepository struct {
+	db *sql.DB
+}
+
+func NewAchievementRepository(db *sql.DB) AchievementRepository {
+	return &achievementRepository{db: db}
+}
+
+func (r *achievementRepository) GetAchievementStats(userID string) (*models.AchievementStats, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM beer_posts WHERE user_id = ?),
+			(SELECT COUNT(DISTINCT LOWER(brewery)) FROM beer_posts WHERE user_id = ? AND brewery IS NOT NULL),
+			(SELECT COUNT(DISTINCT br.country)
+			 FROM beer_posts bp
+			 JOIN beers b ON bp.beer_id = b.id
+			 JOIN breweries br ON b.brewery_id = br.id
+			 WHERE bp.user_id = ? AND br.country IS NOT NULL),
+			(SELECT COUNT(DISTINCT venue_id) FROM beer_posts WHERE user_id = ? AND venue_id IS NOT NULL),
+			(SELECT COUNT(*) FROM comments WHERE user_id = ?),
+			(SELECT COUNT(*) FROM votes WHERE user_id = ?),
+			(SELECT COALESCE(SUM(upvotes), 0) FROM beer_posts WHERE user_id = ?),
+			(SELECT longest_streak FROM users WHERE id = ?)
+	`
+
+	stats := &models.AchievementStats{}
+	var longestStreak sql.NullInt64
+	err := r.db.QueryRow(query, userID, userID, userID, userID, userID, userID, userID, userID).Scan(
+		&stats.PostCount, &stats.DistinctBreweries, &stats.DistinctCountries, &stats.DistinctVenues,
+		&stats.CommentCount, &stats.VotesCast, &stats.UpvotesReceived, &longestStreak,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get achievement stats: %w", err)
+	}
+	stats.LongestStreakDays = int(longestStreak.Int64)
+
+	return stats, nil
+}
+
+func (r *achievementRepository) GetUnlocks(userID string) ([]models.AchievementUnlock, error) {
+	rows, err := r.db.Query(`
+		SELECT user_id, achievement_id, unlocked_at
+		FROM user_achievements
+		WHERE user_id = ?
+		ORDER BY unlocked_at ASC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get achievements: %w", err)
+	}
+	defer rows.Close()
+
+	unlocks := []models.AchievementUnlock{}
+	for rows.Next() {
+		var unlock models.AchievementUnlock
+		if err := rows.Scan(&unlock.UserID, &unlock.AchievementID, &unlock.UnlockedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan achievement: %w", err)
+		}
+		unlocks = append(unlocks, unlock)
+	}
+
+	return unlocks, nil
+}
+
+// AddUnlocks stores the achievements as unlocked for the user and returns the
+// ones that were not unlocked before.
+func (r *achievementRepository) AddUnlocks(userID string, achievementIDs []string) ([]models.AchievementUnlock, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	unlocked := []models.AchievementUnlock{}
+	for _, achievementID := range achievementIDs {
+		result, err := tx.Exec(`
+			INSERT OR IGNORE INTO user_achievements (user_id, achievement_id, unlocked_at)
+			VALUES (?, ?, ?)
+		`, userID, achievementID, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unlock achievement: %w", err)
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			unlocked = append(unlocked, models.AchievementUnlock{UserID: userID, AchievementID: achievementID, UnlockedAt: now})
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit achievements: %w", err)
+	}
+	return unlocked, nil
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/batku/beerreal/internal/models"
+)
+
+type CatalogRepository interface {
+	GetBeerByID(beerID string) (*models.Beer, error)
+	FindBeerByAlias(alias string) (*models.Beer, error)
+	SearchBeers(normalizedQuery string, limit int) ([]models.Beer, error)
+	ImportCatalog(breweries []models.Brewery, beers []models.Beer) error
+}
+
+// beerSelectColumns selects a beer (b) with its brewery (br); keep it in sync
+// with scanBeer.
+const beerSelectColumns = `b.id, b.brewery_id, br.name, b.name, b.style, b.abv, b.created_at, b.updated_at`
+
+func scanBeer(row rowScanner, beer *models.Beer) error {
+	return row.Scan(
+		&beer.ID, &beer.BreweryID, &beer.BreweryName, &beer.Name,
+		&beer.Style, &beer.ABV, &beer.CreatedAt, &beer.UpdatedAt,
+	)
+}
+
+type catalogRepository struct {
+	db *sql.DB
+}
+
+func NewCatalogRepository(db *sql.DB) CatalogRepository {
+	return &catalogRepository{db: db}
+}
+
+func (r *catalogRepository) GetBeerByID(beerID string) (*models.Beer, error) {
+	query := `
+		SELECT ` + beerSelectColumns + `
+		FROM beers b
+		LEFT JOIN breweries br ON b.brewery_id = br.id
+		WHERE b.id = ?
+	`
+
+	beer := &models.Beer{}
+	if err := scanBeer(r.db.QueryRow(query, beerID), beer); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get beer: %w", err)
+	}
+
+	return beer, nil
+}
+
+// FindBeerByAlias returns the beer whose normalized alias matches exactly.
+func (r *catalogRepository) FindBeerByAlias(alias string) (*models.Beer, error) {
+	query := `
+		SELECT ` + beerSelectColumns + `
+		FROM beer_aliases a
+		JOIN beers b ON a.beer_id = b.id
+		LEFT JOIN breweries br ON b.brewery_id = br.id
+		WHERE a.alias = ?
+	`
+
+	beer := &models.Beer{}
+	if err := scanBeer(r.db.QueryRow(query, alias), beer); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find beer: %w", err)
+	}
+
+	return beer, nil
+}
+
+// SearchBeers matches the normalized query against the start of any word of
+// a beer's aliases, ranking matches at the start of an alias first.
+func (r *catalogRepository) SearchBeers(normalizedQuery string, limit int) ([]models.Beer, error) {
+	prefix := escapeLike(normalizedQuery) + "%"
+	wordPrefix := "% " + prefix
+
+	query := `
+		SELECT ` + beerSelectColumns + `
+		FROM beers b
+		LEFT JOIN breweries br ON b.brewery_id = br.id
+		JOIN (
+			SELECT beer_id, MIN(CASE WHEN alias LIKE ? ESCAPE '\' THEN 0 ELSE 1 END) AS rank
+			FROM beer_aliases
+			WHERE alias LIKE ? ESCAPE '\' OR alias LIKE ? ESCAPE '\'
+			GROUP BY beer_id
+		) m ON m.beer_id = b.id
+		ORDER BY m.rank ASC, b.name ASC
+		LIMIT ?
+	`
+
+	rows, err := r.db.Query(query, prefix, prefix, wordPrefix, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search beers: %w", err)
+	}
+	defer rows.Close()
+
+	beers := []models.Beer{}
+	for rows.Next() {
+		beer := models.Beer{}
+		if err := scanBeer(rows, &beer); err != nil {
+			return nil, fmt.Errorf("failed to scan beer: %w", err)
+		}
+		beers = append(beers, beer)
+	}
+
+	return beers, nil
+}
+
+// ImportCatalog upserts breweries and beers by ID in a single transaction.
+// Each beer's aliases are (re)pointed at that beer.
+func (r *catalogRepository) ImportCatalog(breweries []models.Brewery, beers []models.Beer) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for _, brewery := range breweries {
+		_, err := tx.Exec(`
+			INSERT INTO breweries (id, name, country, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT(id) DO UPDATE SET
+				name = excluded.name,
+				country = COALESCE(excluded.country, breweries.country),
+				updated_at = excluded.updated_at
+		`, brewery.ID, brewery.Name, brewery.Country, now, now)
+		if err != nil {
+			return fmt.Errorf("failed to import brewery %q: %w", brewery.Name, err)
+		}
+	}
+
+	for _, beer := range beers {
+		_, err := tx.Exec(`
+			INSERT INTO beers (id, brewery_id, name, style, abv, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(id) DO UPDATE SET
+				brewery_id = excluded.brewery_id,
+				name = excluded.name,
+				style = excluded.style,
+				abv = excluded.abv,
+				updated_at = excluded.updated_at
+		`, beer.ID, beer.BreweryID, beer.Name, beer.Style, beer.ABV, now, now)
+		if err != nil {
+			return fmt.Errorf("failed to import beer %q: %w", beer.Name, err)
+		}
+
+		for _, alias := range beer.Aliases {
+			_, err := tx.Exec(`
+				INSERT INTO beer_aliases (alias, beer_id) VALUES (?, ?)
+				ON CONFLICT(alias) DO UPDATE SET beer_id = excluded.beer_id
+			`, alias, beer.ID)
+			if err != nil {
+				return fmt.Errorf("failed to import alias %q: %w", alias, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit catalog import: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/batku/beerreal/internal/models"
+)
+
+type DeviceRepository interface {
+	RegisterDevice(device *models.Device) error
+	DeleteDevice(userID, token string) error
+	DeleteToken(token string) error
+	GetDeviceTokens(userID string) ([]string, error)
+}
+
+type deviceRepository struct {
+	db *sql.DB
+}
+
+func NewDeviceRepository(db *sql.DB) DeviceRepository {
+	return &deviceRepository{db: db}
+}
+
+// RegisterDevice stores the token for the user. A token registered before is
+// moved to the user, since an app install only has one signed-in user.
+func (r *deviceRepository) RegisterDevice(device *models.Device) error {
+	now := time.Now()
+	_, err := r.db.Exec(`
+		INSERT INTO devices (token, user_id, platform, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(token) DO UPDATE SET
+			user_id = excluded.user_id,
+			platform = excluded.platform,
+			updated_at = excluded.updated_at
+	`, device.Token, device.UserID, device.Platform, now, now)
+	if err != nil {
+		return fmt.Errorf("failed to register device: %w", err)
+	}
+
+	device.CreatedAt = now
+	device.UpdatedAt = now
+	return nil
+}
+
+// DeleteDevice removes the token if it belongs to the user.
+func (r *deviceRepository) DeleteDevice(userID, token string) error {
+	if _, err := r.db.Exec(`DELETE FROM devices WHERE user_id = ? AND token = ?`, userID, token); err != nil {
+		return fmt.Errorf("failed to delete device: %w", err)
+	}
+	return nil
+}
+
+// DeleteToken removes a token regardless of its user, e.g. after the push
+// service reported it as invalid.
+func (r *deviceRepository) DeleteToken(token string) error {
+	if _, err := r.db.Exec(`DELETE FROM devices WHERE token = ?`, token); err != nil {
+		return fmt.Errorf("failed to delete device token: %w", err)
+	}
+	return nil
+}
+
+func (r *deviceRepository) GetDeviceTokens(userID string) ([]string, error) {
+	rows, err := r.db.Query(`SELECT token FROM devices WHERE user_id = ? ORDER BY updated_at DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := []string{}
+	for rows.Next() {
+		var token string
+		if err := rows.Scan(&token); err != nil {
+			return nil, fmt.Errorf("failed to scan device token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, nil
+}
@@ -0,0 +1,54 @@
+package repository
+
+import "math"
+
+const earthRadiusKm = 6371.0
+
+type geoBox struct {
+	minLat, maxLat float64
+	minLng, maxLng float64
+}
+
+// boundingBox returns a box containing every point within radiusKm of the
+// given point. If the box crosses the antimeridian, minLng is greater than
+// maxLng.
+func boundingBox(lat, lng, radiusKm float64) geoBox {
+	latDelta := radiusKm / earthRadiusKm * 180 / math.Pi
+	box := geoBox{
+		minLat: math.Max(-90, lat-latDelta),
+		maxLat: math.Min(90, lat+latDelta),
+		minLng: -180,
+		maxLng: 180,
+	}
+
+	// Near the poles the box covers every longitude
+	if box.minLat > -90 && box.maxLat < 90 {
+		lngDelta := latDelta / math.Cos(lat*math.Pi/180)
+		if lngDelta < 180 {
+			box.minLng = normalizeLongitude(lng - lngDelta)
+			box.maxLng = normalizeLongitude(lng + lngDelta)
+		}
+	}
+
+	return box
+}
+
+func normalizeLongitude(lng float64) float64 {
+	if lng < -180 {
+		return lng + 360
+	}
+	if lng > 180 {
+		return lng - 360
+	}
+	return lng
+}
+
+// haversineKm returns the great-circle distance between two points.
+func haversineKm(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLng := (lng2 - lng1) * toRad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/batku/beerreal/internal/models"
+	"github.com/google/uuid"
+)
+
+type NotificationRepository interface {
+	CreateNotification(notification *models.Notification) error
+	// GetNotifications returns up to limit notifications for the user, newest
+	// first. A non-nil before resumes after the notification with that
+	// creation time and ID.
+	GetNotifications(userID string, before *NotificationCursor, limit int) ([]models.Notification, error)
+	// RefreshUnreadVoteNotification updates the recipient's unread vote
+	// notification from the same voter on the same post with the new vote
+	// and moves it to the top of the inbox. It reports whether one existed.
+	RefreshUnreadVoteNotification(notification *models.Notification) (bool, error)
+	CountUnread(userID string) (int, error)
+	MarkRead(userID string, notificationIDs []string) error
+	MarkAllRead(userID string) error
+	// GetSettings returns the user's notification settings, with defaults for
+	// users who never changed them, or nil if the user does not exist.
+	GetSettings(userID string) (*models.NotificationSettings, error)
+	SaveSettings(userID string, settings *models.NotificationSettings) error
+	// GetDailyMomentRecipients returns the users with at least one registered
+	// device who haven't turned the daily moment off.
+	GetDailyMomentRecipients() ([]models.DailyMomentRecipient, error)
+	// MarkDailyMomentSent records that the user got the daily moment of the
+	// given local date. It reports false if they already had.
+	MarkDailyMomentSent(userID, date string) (bool, error)
+}
+
+// NotificationCursor is the position of a notification in a user's inbox.
+type NotificationCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+type notificationRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationRepository(db *sql.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+// CreateNotification stores the notification. Times are kept in UTC so that
+// cursors compare consistently.
+func (r *notificationRepository) CreateNotification(notification *models.Notification) error {
+	notification.ID = uuid.New().String()
+	notification.CreatedAt = time.Now().UTC()
+
+	_, err := r.db.Exec(`
+		INSERT INTO notifications (id, user_id, type, actor_id, post_id, comment_id, vote_type, text, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, notification.ID, notification.UserID, notification.Type, notification.ActorID,
+		notification.PostID, notification.CommentID, notification.VoteType, notification.Text,
+		notification.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+	return nil
+}
+
+func (r *notificationRepository) GetNotifications(userID string, before *NotificationCursor, limit int) ([]models.Notification, error) {
+	query := `
+		SELECT n.id, n.user_id, n.type, n.actor_id, u.username, u.profile_image_data,
+		       n.post_id, n.comment_id, n.vote_type, n.text, n.read_at, n.created_at
+		FROM notifications n
+		JOIN users u ON n.actor_id = u.id
+		WHERE n.user_id = ?`
+	args := []interface{}{userID}
+	if before != nil {
+		query += ` AND (n.created_at < ? OR (n.created_at = ? AND n.id < ?))`
+		createdAt := before.CreatedAt.UTC()
+		args = append(args, createdAt, createdAt, before.ID)
+	}
+	query += `
+		ORDER BY n.created_at DESC, n.id DESC
+		LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		var n models.Notification
+		err := rows.Scan(
+			&n.ID, &n.UserID, &n.Type, &n.ActorID, &n.ActorUsername, &n.ActorProfileImageData,
+			&n.PostID, &n.CommentID, &n.VoteType, &n.Text, &n.ReadAt, &n.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		notifications = append(notifications, n)
+	}
+
+	return notifications, nil
+}
+
+func (r *notificationRepository) RefreshUnreadVoteNotification(notification *models.Notification) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE notifications SET vote_type = ?, created_at = ?
+		WHERE user_id = ? AND type = ? AND actor_id = ? AND post_id = ? AND read_at IS NULL
+	`, notification.VoteType, time.Now().UTC(), notification.UserID, models.NotificationTypeVote,
+		notification.ActorID, notification.PostID)
+	if err != nil {
+		return false, fmt.Errorf("failed to refresh vote notification: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to refresh vote notification: %w", err)
+	}
+	return updated > 0, nil
+}
+
+func (r *notificationRepository) CountUnread(userID string) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+	return count, nil
+}
+
+// MarkRead marks the user's notifications with the given IDs as read; IDs of
+// other users' notifications are ignored.
+func (r *notificationRepository) MarkRead(userID string, notificationIDs []string) error {
+	if len(notificationIDs) == 0 {
+		return nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(notificationIDs)), ", ")
+	args := []interface{}{time.Now().UTC(), userID}
+	for _, id := range notificationIDs {
+		args = append(args, id)
+	}
+
+	query := `UPDATE notifications SET read_at = ? WHERE user_id = ? AND read_at IS NULL AND id IN (` + placeholders + `)`
+	if _, err := r.db.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+	return nil
+}
+
+func (r *notificationRepository) MarkAllRead(userID string) error {
+	_, err := r.db.Exec(`UPDATE notifications SET read_at = ? WHERE user_id = ? AND read_at IS NULL`, time.Now().UTC(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+	return nil
+}
+
+func (r *notificationRepository) GetSettings(userID string) (*models.NotificationSettings, error) {
+	var settings models.NotificationSettings
+	err := r.db.QueryRow(`
+		SELECT COALESCE(s.votes, 1), COALESCE(s.comments, 1), COALESCE(s.mentions, 1),
+		       COALESCE(s.friend_requests, 1), COALESCE(s.friend_accepted, 1), COALESCE(s.daily_moment, 1),
+		       s.quiet_hours_start, s.quiet_hours_end, COALESCE(u.time_zone, 'UTC')
+		FROM users u
+		LEFT JOIN notification_settings s ON s.user_id = u.id
+		WHERE u.id = ?
+	`, userID).Scan(
+		&settings.Votes, &settings.Comments, &settings.Mentions,
+		&settings.FriendRequests, &settings.FriendAccepted, &settings.DailyMoment,
+		&settings.QuietHoursStart, &settings.QuietHoursEnd, &settings.TimeZone,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification settings: %w", err)
+	}
+	return &settings, nil
+}
+
+func (r *notificationRepository) SaveSettings(userID string, settings *models.NotificationSettings) error {
+	_, err := r.db.Exec(`
+		INSERT INTO notification_settings (user_id, votes, comments, mentions, friend_requests, friend_accepted,
+			daily_moment, quiet_hours_start, quiet_hours_end, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			votes = excluded.votes,
+			comments = excluded.comments,
+			mentions = excluded.mentions,
+			friend_requests = excluded.friend_requests,
+			friend_accepted = excluded.friend_accepted,
+			daily_moment = excluded.daily_moment,
+			quiet_hours_start = excluded.quiet_hours_start,
+			quiet_hours_end = excluded.quiet_hours_end,
+			updated_at = excluded.updated_at
+	`, userID, settings.Votes, settings.Comments, settings.Mentions, settings.FriendRequests, settings.FriendAccepted,
+		settings.DailyMoment, settings.QuietHoursStart, settings.QuietHoursEnd, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to save notification settings: %w", err)
+	}
+	return nil
+}
+
+func (r *notificationRepository) GetDailyMomentRecipients() ([]models.DailyMomentRecipient, error) {
+	rows, err := r.db.Query(`
+		SELECT u.id, u.time_zone, u.last_daily_moment_date
+		FROM users u
+		LEFT JOIN notification_settings s ON s.user_id = u.id
+		WHERE EXISTS (SELECT 1 FROM devices d WHERE d.user_id = u.id)
+		  AND COALESCE(s.daily_moment, 1) = 1
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily moment recipients: %w", err)
+	}
+	defer rows.Close()
+
+	recipients := []models.DailyMomentRecipient{}
+	for rows.Next() {
+		var recipient models.DailyMomentRecipient
+		if err := rows.Scan(&recipient.UserID, &recipient.TimeZone, &recipient.LastMomentDate); err != nil {
+			return nil, fmt.Errorf("failed to scan daily moment recipient: %w", err)
+		}
+		recipients = append(recipients, recipient)
+	}
+	return recipients, nil
+}
+
+func (r *notificationRepository) MarkDailyMomentSent(userID, date string) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE users SET last_daily_moment_date = ?
+		WHERE id = ? AND (last_daily_moment_date IS NULL OR last_daily_moment_date != ?)
+	`, date, userID, date)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark daily moment sent: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to mark daily moment sent: %w", err)
+	}
+	return updated > 0, nil
+}
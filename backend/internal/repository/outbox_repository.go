@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/batku/beerreal/internal/models"
+)
+
+type OutboxRepository interface {
+	// GetDue returns up to limit messages whose next attempt is due, oldest
+	// first. Dead-lettered messages are never returned.
+	GetDue(now time.Time, limit int) ([]models.OutboxMessage, error)
+	// MarkDelivered removes a message that was handled successfully.
+	MarkDelivered(id int64) error
+	// MarkFailed records a failed attempt and when to try again.
+	MarkFailed(id int64, lastError string, nextAttemptAt time.Time) error
+	// MarkDeadLettered stops delivery of the message, keeping it for
+	// inspection.
+	MarkDeadLettered(id int64, lastError string) error
+}
+
+type outboxRepository struct {
+	db *sql.DB
+}
+
+func NewOutboxRepository(db *sql.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// insertOutboxMessages records messages as part of the caller's transaction,
+// so they are stored if and only if the change that caused them is. Times are
+// kept in UTC so that due times compare consistently.
+func insertOutboxMessages(tx *sql.Tx, messages []models.OutboxMessage) error {
+	now := time.Now().UTC()
+	for i := range messages {
+		result, err := tx.Exec(`
+			INSERT INTO outbox (type, payload, next_attempt_at, created_at)
+			VALUES (?, ?, ?, ?)
+		`, messages[i].Type, string(messages[i].Payload), now, now)
+		if err != nil {
+			return fmt.Errorf("failed to insert outbox message: %w", err)
+		}
+		if messages[i].ID, err = result.LastInsertId(); err != nil {
+			return fmt.Errorf("failed to get outbox message ID: %w", err)
+		}
+		messages[i].NextAttemptAt = now
+		messages[i].CreatedAt = now
+	}
+	return nil
+}
+
+func (r *outboxRepository) GetDue(now time.Time, limit int) ([]models.OutboxMessage, error) {
+	rows, err := r.db.Query(`
+		SELECT id, type, payload, attempts, last_error, next_attempt_at, created_at
+		FROM outbox
+		WHERE dead_lettered_at IS NULL AND next_attempt_at <= ?
+		ORDER BY id ASC
+		LIMIT ?
+	`, now.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due outbox messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages := []models.OutboxMessage{}
+	for rows.Next() {
+		var message models.OutboxMessage
+		var payload string
+		err := rows.Scan(
+			&message.ID, &message.Type, &payload, &message.Attempts, &message.LastError,
+			&message.NextAttemptAt, &message.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outbox message: %w", err)
+		}
+		message.Payload = []byte(payload)
+		messages = append(messages, message)
+	}
+
+	return messages, nil
+}
+
+func (r *outboxRepository) MarkDelivered(id int64) error {
+	if _, err := r.db.Exec(`DELETE FROM outbox WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to mark outbox message delivered: %w", err)
+	}
+	return nil
+}
+
+func (r *outboxRepository) MarkFailed(id int64, lastError string, nextAttemptAt time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE outbox SET attempts = attempts + 1, last_error = ?, next_attempt_at = ?
+		WHERE id = ?
+	`, lastError, nextAttemptAt.UTC(), id)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox message failed: %w", err)
+	}
+	return nil
+}
+
+func (r *outboxRepository) MarkDeadLettered(id int64, lastError string) error {
+	_, err := r.db.Exec(`
+		UPDATE outbox SET attempts = attempts + 1, last_error = ?, dead_lettered_at = ?
+		WHERE id = ?
+	`, lastError, time.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("failed to dead-letter outbox message: %w", err)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -15,30 +16,97 @@ type PostRepository interface {
 	GetPostByID(postID string, userID string) (*models.BeerPost, error)
 	GetPosts(userID string, limit, offset int) ([]models.BeerPost, int, error)
 	GetUserPosts(targetUserID string, currentUserID string, limit, offset int) ([]models.BeerPost, int, error)
+	GetNearbyPosts(viewerID string, lat, lng, radiusKm float64, since time.Time, limit int) ([]models.NearbyPost, error)
+	GetVenuePosts(venueID string, viewerID string, limit, offset int) ([]models.BeerPost, int, error)
+	GetVenueStats(venueID string, viewerID string) (*models.VenueStats, error)
+	GetVenueTopVisitors(venueID string, viewerID string, since time.Time, limit int) ([]models.VenueVisitor, error)
+	GetBeerPosts(beerID string, viewerID string, limit, offset int) ([]models.BeerPost, int, error)
+	GetBeerStats(beerID string, viewerID string, topLocations int) (*models.BeerStats, error)
 	GetUserByID(userID string) (*models.User, error)
 	CreateOrUpdateUser(user *models.User) error
-	GetCommentsByPostID(postID string) ([]models.Comment, error)
+	GetCommentsByPostID(postID string, viewerID string) ([]models.Comment, error)
+	GetCommentsByUserID(userID string) ([]models.Comment, error)
+	GetVotesByUserID(userID string) ([]models.Vote, error)
 	GetVoteByUserAndPost(userID, postID string) (*models.Vote, error)
-	AddVote(vote *models.Vote) error
-	UpdateVote(vote *models.Vote) error
-	DeleteVote(voteID string) error
-	UpdatePostVotes(postID string, upvotes, downvotes int) error
+	// GetPostVoters returns up to limit voters on the post, most recent vote
+	// first, optionally only with one vote type, leaving out users the viewer
+	// has blocked or muted. A non-nil before resumes after that vote.
+	GetPostVoters(postID, viewerID string, voteType models.VoteType, before *VoteCursor, limit int) ([]models.Voter, error)
+	// GetVotedPosts returns up to limit posts the user voted on and can still
+	// see, most recent vote first. A non-nil before resumes after that vote.
+	GetVotedPosts(userID string, before *VoteCursor, limit int) ([]models.VotedPost, error)
+	// SaveVote stores the vote, or deletes it if removed is set, together with
+	// the changes to the post's vote counts and the outbox messages for its
+	// side effects, in one transaction. The vote is only written if the stored
+	// vote is still previous (empty for none); otherwise a concurrent request
+	// got there first and nothing is changed. It returns the post's current
+	// vote counts and whether the vote was saved.
+	SaveVote(vote *models.Vote, previous models.VoteType, removed bool, upvoteChange, downvoteChange int, outbox []models.OutboxMessage) (upvotes, downvotes int, saved bool, err error)
 	AddComment(comment *models.Comment) error
 }
 
+var ErrPostNotFound = errors.New("post not found")
+
+// VoteCursor is the position of a vote in a voter list or vote history. Votes
+// are ordered by their last change, so a changed vote moves to the front.
+type VoteCursor struct {
+	VotedAt time.Time
+	ID      string
+}
+
+// postSelectColumns is the column list (posts bp joined with their author u)
+// shared by every query that returns posts; keep it in sync with scanPost. The
+// last column counts the author's posts at the post's venue for the regular
+// badge.
+const postSelectColumns = `
+	bp.id, bp.user_id, u.username, u.profile_image_data,
+	bp.caption, bp.image_data, bp.location, bp.timestamp,
+	bp.upvotes, bp.downvotes, bp.created_at, bp.updated_at,
+	bp.visibility, bp.beer_id, bp.beer_name, bp.brewery, bp.beer_style, bp.abv,
+	bp.serving_type, bp.rating, bp.latitude, bp.longitude, bp.location_accuracy,
+	bp.venue_id, (SELECT v.name FROM venues v WHERE v.id = bp.venue_id),
+	(SELECT COUNT(*) FROM beer_posts vp WHERE vp.venue_id = bp.venue_id AND vp.user_id = bp.user_id)`
+
+// visiblePostFilter restricts posts (bp joined with their author u) to those
+// the viewer may see: their own posts, public posts from public accounts, and
+// non-private posts from accepted friends. The viewer ID must be bound to all
+// three placeholders; an empty viewer only sees public posts.
+const visiblePostFilter = `(
+	bp.user_id = ?
+	OR (bp.visibility = 'PUBLIC' AND u.is_private = 0)
+	OR (bp.visibility != 'PRIVATE' AND EXISTS (
+		SELECT 1 FROM friendships f
+		WHERE f.status = 'ACCEPTED'
+		  AND ((f.user_id = ? AND f.friend_id = bp.user_id) OR (f.friend_id = ? AND f.user_id = bp.user_id))
+	))
+)`
+
+// hiddenAuthorFilter excludes content whose author (the %s column) has been
+// blocked or muted by the viewer bound to the placeholder.
+const hiddenAuthorFilter = `NOT EXISTS (
+	SELECT 1 FROM user_restrictions ur
+	WHERE ur.user_id = ? AND ur.target_user_id = %s
+)`
+
 type postRepository struct {
 	db *sql.DB
+	// venueRegularThreshold is how many posts an author needs at a venue for
+	// their posts there to be marked as a regular's; zero disables the badge.
+	venueRegularThreshold int
 }
 
-func NewPostRepository(db *sql.DB) PostRepository {
-	return &postRepository{db: db}
+func NewPostRepository(db *sql.DB, venueRegularThreshold int) PostRepository {
+	return &postRepository{db: db, venueRegularThreshold: venueRegularThreshold}
 }
 
 func (r *postRepository) CreatePost(post *models.BeerPost) error {
 	log.Printf("[Repository] CreatePost called for userID: %s", post.UserID)
 	query := `
-		INSERT INTO beer_posts (id, user_id, caption, image_data, location, timestamp, upvotes, downvotes, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO beer_posts (id, user_id, caption, image_data, location, visibility,
+		                        beer_id, beer_name, brewery, beer_style, abv, serving_type, rating,
+		                        latitude, longitude, location_accuracy, venue_id,
+		                        timestamp, upvotes, downvotes, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	post.ID = uuid.New().String()
@@ -50,7 +118,10 @@ func (r *postRepository) CreatePost(post *models.BeerPost) error {
 
 	log.Printf("[Repository] Inserting post with ID: %s, imageDataLength: %d", post.ID, len(post.ImageData))
 	_, err := r.db.Exec(query, post.ID, post.UserID, post.Caption, post.ImageData,
-		post.Location, post.Timestamp, post.Upvotes, post.Downvotes,
+		post.Location, post.Visibility,
+		post.BeerID, post.BeerName, post.Brewery, post.BeerStyle, post.ABV, post.ServingType, post.Rating,
+		post.Latitude, post.Longitude, post.LocationAccuracy, post.VenueID,
+		post.Timestamp, post.Upvotes, post.Downvotes,
 		post.CreatedAt, post.UpdatedAt)
 
 	if err != nil {
@@ -67,6 +138,10 @@ func (r *postRepository) CreatePost(post *models.BeerPost) error {
 		return fmt.Errorf("failed to update user post count: %w", err)
 	}
 
+	if err := r.markRegular(post); err != nil {
+		log.Printf("[Repository] ERROR: Failed to mark regular: %v", err)
+	}
+
 	log.Printf("[Repository] Post created successfully: %s", post.ID)
 	return nil
 }
@@ -74,48 +149,29 @@ func (r *postRepository) CreatePost(post *models.BeerPost) error {
 func (r *postRepository) GetPostByID(postID string, userID string) (*models.BeerPost, error) {
 	log.Printf("[Repository] GetPostByID called - postID: %s, userID: %s", postID, userID)
 	query := `
-		SELECT 
-			bp.id, bp.user_id, u.username, u.profile_image_data,
-			bp.caption, bp.image_data, bp.location, bp.timestamp,
-			bp.upvotes, bp.downvotes, bp.created_at, bp.updated_at
+		SELECT ` + postSelectColumns + `
 		FROM beer_posts bp
 		JOIN users u ON bp.user_id = u.id
-		WHERE bp.id = ?
+		WHERE bp.id = ? AND ` + visiblePostFilter + `
 	`
 
 	post := &models.BeerPost{}
-	err := r.db.QueryRow(query, postID).Scan(
-		&post.ID, &post.UserID, &post.Username, &post.UserProfileImageData,
-		&post.Caption, &post.ImageData, &post.Location, &post.Timestamp,
-		&post.Upvotes, &post.Downvotes, &post.CreatedAt, &post.UpdatedAt,
-	)
+	err := r.scanPost(r.db.QueryRow(query, postID, userID, userID, userID), post)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Printf("[Repository] Post not found: %s", postID)
-			return nil, fmt.Errorf("post not found")
+			return nil, ErrPostNotFound
 		}
 		log.Printf("[Repository] ERROR: Database query failed: %v", err)
 		return nil, fmt.Errorf("failed to get post: %w", err)
 	}
 
 	log.Printf("[Repository] Post found, fetching comments for post: %s", postID)
-	// Get comments
-	comments, err := r.GetCommentsByPostID(postID)
-	if err != nil {
+	if err := r.hydratePost(post, userID); err != nil {
 		log.Printf("[Repository] ERROR: Failed to get comments: %v", err)
 		return nil, err
 	}
-	post.Comments = comments
-
-	// Get user's vote if they're authenticated
-	if userID != "" {
-		vote, _ := r.GetVoteByUserAndPost(userID, postID)
-		if vote != nil {
-			post.HasUserVoted = true
-			post.UserVoteType = &vote.VoteType
-		}
-	}
 
 	log.Printf("[Repository] GetPostByID successful for post: %s", postID)
 	return post, nil
@@ -123,70 +179,33 @@ func (r *postRepository) GetPostByID(postID string, userID string) (*models.Beer
 
 func (r *postRepository) GetPosts(userID string, limit, offset int) ([]models.BeerPost, int, error) {
 	log.Printf("[Repository] GetPosts called - userID: %s, limit: %d, offset: %d", userID, limit, offset)
+	where := visiblePostFilter + ` AND ` + fmt.Sprintf(hiddenAuthorFilter, "bp.user_id")
+	args := []interface{}{userID, userID, userID, userID}
+
 	// Get total count
 	var totalCount int
-	countQuery := `SELECT COUNT(*) FROM beer_posts`
-	err := r.db.QueryRow(countQuery).Scan(&totalCount)
+	countQuery := `SELECT COUNT(*) FROM beer_posts bp JOIN users u ON bp.user_id = u.id WHERE ` + where
+	err := r.db.QueryRow(countQuery, args...).Scan(&totalCount)
 	if err != nil {
 		log.Printf("[Repository] ERROR: Failed to count posts: %v", err)
 		return nil, 0, fmt.Errorf("failed to count posts: %w", err)
 	}
-	log.Printf("[Repository] Total posts in DB: %d", totalCount)
+	log.Printf("[Repository] Total posts visible in DB: %d", totalCount)
 
 	// Get posts
 	query := `
-		SELECT 
-			bp.id, bp.user_id, u.username, u.profile_image_data,
-			bp.caption, bp.image_data, bp.location, bp.timestamp,
-			bp.upvotes, bp.downvotes, bp.created_at, bp.updated_at
+		SELECT ` + postSelectColumns + `
 		FROM beer_posts bp
 		JOIN users u ON bp.user_id = u.id
+		WHERE ` + where + `
 		ORDER BY bp.timestamp DESC
 		LIMIT ? OFFSET ?
 	`
 
 	log.Printf("[Repository] Executing query with limit: %d, offset: %d", limit, offset)
-	rows, err := r.db.Query(query, limit, offset)
+	posts, err := r.queryPosts(query, userID, append(args, limit, offset)...)
 	if err != nil {
-		log.Printf("[Repository] ERROR: Query execution failed: %v", err)
-		return nil, 0, fmt.Errorf("failed to get posts: %w", err)
-	}
-	defer rows.Close()
-
-	posts := []models.BeerPost{}
-	postCount := 0
-	for rows.Next() {
-		postCount++
-		post := models.BeerPost{}
-		err := rows.Scan(
-			&post.ID, &post.UserID, &post.Username, &post.UserProfileImageData,
-			&post.Caption, &post.ImageData, &post.Location, &post.Timestamp,
-			&post.Upvotes, &post.Downvotes, &post.CreatedAt, &post.UpdatedAt,
-		)
-		if err != nil {
-			log.Printf("[Repository] ERROR: Failed to scan post row %d: %v", postCount, err)
-			return nil, 0, fmt.Errorf("failed to scan post: %w", err)
-		}
-		log.Printf("[Repository] Scanned post %d - ID: %s", postCount, post.ID)
-
-		// Get comments for each post
-		comments, err := r.GetCommentsByPostID(post.ID)
-		if err != nil {
-			log.Printf("[Repository] ERROR: Failed to get comments for post %s: %v", post.ID, err)
-			return nil, 0, err
-		}
-		post.Comments = comments
-
-		// Get user's vote if authenticated
-		if userID != "" {
-			vote, _ := r.GetVoteByUserAndPost(userID, post.ID)
-			if vote != nil {
-				post.HasUserVoted = true
-				post.UserVoteType = &vote.VoteType
-			}
-		}
-
-		posts = append(posts, post)
+		return nil, 0, err
 	}
 
 	log.Printf("[Repository] GetPosts successful - returning %d posts (total: %d)", len(posts), totalCount)
@@ -195,11 +214,13 @@ func (r *postRepository) GetPosts(userID string, limit, offset int) ([]models.Be
 
 func (r *postRepository) GetUserPosts(targetUserID string, currentUserID string, limit, offset int) ([]models.BeerPost, int, error) {
 	log.Printf("[Repository] GetUserPosts called - targetUserID: %s, currentUserID: %s, limit: %d, offset: %d", targetUserID, currentUserID, limit, offset)
-	
+	where := `bp.user_id = ? AND ` + visiblePostFilter + ` AND ` + fmt.Sprintf(hiddenAuthorFilter, "bp.user_id")
+	args := []interface{}{targetUserID, currentUserID, currentUserID, currentUserID, currentUserID}
+
 	// Get total count for this user
 	var totalCount int
-	countQuery := `SELECT COUNT(*) FROM beer_posts WHERE user_id = ?`
-	err := r.db.QueryRow(countQuery, targetUserID).Scan(&totalCount)
+	countQuery := `SELECT COUNT(*) FROM beer_posts bp JOIN users u ON bp.user_id = u.id WHERE ` + where
+	err := r.db.QueryRow(countQuery, args...).Scan(&totalCount)
 	if err != nil {
 		log.Printf("[Repository] ERROR: Failed to count user posts: %v", err)
 		return nil, 0, fmt.Errorf("failed to count user posts: %w", err)
@@ -208,77 +229,380 @@ func (r *postRepository) GetUserPosts(targetUserID string, currentUserID string,
 
 	// Get posts
 	query := `
-		SELECT 
-			bp.id, bp.user_id, u.username, u.profile_image_data,
-			bp.caption, bp.image_data, bp.location, bp.timestamp,
-			bp.upvotes, bp.downvotes, bp.created_at, bp.updated_at
+		SELECT ` + postSelectColumns + `
 		FROM beer_posts bp
 		JOIN users u ON bp.user_id = u.id
-		WHERE bp.user_id = ?
+		WHERE ` + where + `
 		ORDER BY bp.timestamp DESC
 		LIMIT ? OFFSET ?
 	`
 
 	log.Printf("[Repository] Executing query with limit: %d, offset: %d", limit, offset)
-	rows, err := r.db.Query(query, targetUserID, limit, offset)
+	posts, err := r.queryPosts(query, currentUserID, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	log.Printf("[Repository] GetUserPosts successful - returning %d posts", len(posts))
+	return posts, totalCount, nil
+}
+
+// GetNearbyPosts returns the newest posts visible to the viewer within
+// radiusKm of the point, created after since. Candidates are prefiltered with
+// a bounding box in SQL and then checked with the haversine distance.
+func (r *postRepository) GetNearbyPosts(viewerID string, lat, lng, radiusKm float64, since time.Time, limit int) ([]models.NearbyPost, error) {
+	box := boundingBox(lat, lng, radiusKm)
+
+	lngFilter := `bp.longitude BETWEEN ? AND ?`
+	args := []interface{}{box.minLat, box.maxLat}
+	if box.minLng > box.maxLng {
+		// The box crosses the antimeridian
+		lngFilter = `(bp.longitude >= ? OR bp.longitude <= ?)`
+	}
+	args = append(args, box.minLng, box.maxLng, since, viewerID, viewerID, viewerID, viewerID)
+
+	query := `
+		SELECT ` + postSelectColumns + `
+		FROM beer_posts bp
+		JOIN users u ON bp.user_id = u.id
+		WHERE bp.latitude BETWEEN ? AND ? AND ` + lngFilter + `
+		  AND bp.timestamp >= ?
+		  AND ` + visiblePostFilter + `
+		  AND ` + fmt.Sprintf(hiddenAuthorFilter, "bp.user_id") + `
+		ORDER BY bp.timestamp DESC
+	`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nearby posts: %w", err)
+	}
+	defer rows.Close()
+
+	posts := []models.NearbyPost{}
+	for rows.Next() && len(posts) < limit {
+		post := models.NearbyPost{}
+		if err := r.scanPost(rows, &post.BeerPost); err != nil {
+			return nil, fmt.Errorf("failed to scan post: %w", err)
+		}
+		distance := haversineKm(lat, lng, *post.Latitude, *post.Longitude)
+		if distance > radiusKm {
+			continue
+		}
+		post.DistanceKm = distance
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get nearby posts: %w", err)
+	}
+	rows.Close()
+
+	for i := range posts {
+		if err := r.hydratePost(&posts[i].BeerPost, viewerID); err != nil {
+			return nil, err
+		}
+	}
+
+	return posts, nil
+}
+
+func (r *postRepository) GetBeerPosts(beerID string, viewerID string, limit, offset int) ([]models.BeerPost, int, error) {
+	where := `bp.beer_id = ? AND ` + visiblePostFilter + ` AND ` + fmt.Sprintf(hiddenAuthorFilter, "bp.user_id")
+	args := []interface{}{beerID, viewerID, viewerID, viewerID, viewerID}
+
+	var totalCount int
+	countQuery := `SELECT COUNT(*) FROM beer_posts bp JOIN users u ON bp.user_id = u.id WHERE ` + where
+	if err := r.db.QueryRow(countQuery, args...).Scan(&totalCount); err != nil {
+		return nil, 0, fmt.Errorf("failed to count beer posts: %w", err)
+	}
+
+	query := `
+		SELECT ` + postSelectColumns + `
+		FROM beer_posts bp
+		JOIN users u ON bp.user_id = u.id
+		WHERE ` + where + `
+		ORDER BY bp.timestamp DESC
+		LIMIT ? OFFSET ?
+	`
+	posts, err := r.queryPosts(query, viewerID, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return posts, totalCount, nil
+}
+
+// GetBeerStats aggregates the beer's posts that are visible to the viewer.
+func (r *postRepository) GetBeerStats(beerID string, viewerID string, topLocations int) (*models.BeerStats, error) {
+	where := `bp.beer_id = ? AND ` + visiblePostFilter + ` AND ` + fmt.Sprintf(hiddenAuthorFilter, "bp.user_id")
+	args := []interface{}{beerID, viewerID, viewerID, viewerID, viewerID}
+
+	stats := &models.BeerStats{TopLocations: []models.LocationCount{}}
+	query := `
+		SELECT COUNT(*), COUNT(bp.rating), AVG(bp.rating),
+		       COALESCE(SUM(bp.upvotes), 0), COALESCE(SUM(bp.downvotes), 0)
+		FROM beer_posts bp
+		JOIN users u ON bp.user_id = u.id
+		WHERE ` + where
+	var averageRating sql.NullFloat64
+	err := r.db.QueryRow(query, args...).Scan(
+		&stats.PostCount, &stats.RatingCount, &averageRating,
+		&stats.Upvotes, &stats.Downvotes,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get beer stats: %w", err)
+	}
+	if averageRating.Valid {
+		stats.AverageRating = &averageRating.Float64
+	}
+	if totalVotes := stats.Upvotes + stats.Downvotes; totalVotes > 0 {
+		ratio := float64(stats.Upvotes) / float64(totalVotes)
+		stats.UpvoteRatio = &ratio
+	}
+
+	locationsQuery := `
+		SELECT bp.location, COUNT(*) AS post_count
+		FROM beer_posts bp
+		JOIN users u ON bp.user_id = u.id
+		WHERE ` + where + ` AND bp.location IS NOT NULL AND TRIM(bp.location) != ''
+		GROUP BY bp.location
+		ORDER BY post_count DESC, bp.location ASC
+		LIMIT ?
+	`
+	rows, err := r.db.Query(locationsQuery, append(args, topLocations)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get beer locations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var location models.LocationCount
+		if err := rows.Scan(&location.Location, &location.PostCount); err != nil {
+			return nil, fmt.Errorf("failed to scan beer location: %w", err)
+		}
+		stats.TopLocations = append(stats.TopLocations, location)
+	}
+
+	return stats, nil
+}
+
+func (r *postRepository) GetVenuePosts(venueID string, viewerID string, limit, offset int) ([]models.BeerPost, int, error) {
+	where := `bp.venue_id = ? AND ` + visiblePostFilter + ` AND ` + fmt.Sprintf(hiddenAuthorFilter, "bp.user_id")
+	args := []interface{}{venueID, viewerID, viewerID, viewerID, viewerID}
+
+	var totalCount int
+	countQuery := `SELECT COUNT(*) FROM beer_posts bp JOIN users u ON bp.user_id = u.id WHERE ` + where
+	if err := r.db.QueryRow(countQuery, args...).Scan(&totalCount); err != nil {
+		return nil, 0, fmt.Errorf("failed to count venue posts: %w", err)
+	}
+
+	query := `
+		SELECT ` + postSelectColumns + `
+		FROM beer_posts bp
+		JOIN users u ON bp.user_id = u.id
+		WHERE ` + where + `
+		ORDER BY bp.timestamp DESC
+		LIMIT ? OFFSET ?
+	`
+	posts, err := r.queryPosts(query, viewerID, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return posts, totalCount, nil
+}
+
+// GetVenueStats aggregates the venue's posts that are visible to the viewer.
+func (r *postRepository) GetVenueStats(venueID string, viewerID string) (*models.VenueStats, error) {
+	where := `bp.venue_id = ? AND ` + visiblePostFilter + ` AND ` + fmt.Sprintf(hiddenAuthorFilter, "bp.user_id")
+	args := []interface{}{venueID, viewerID, viewerID, viewerID, viewerID}
+
+	stats := &models.VenueStats{}
+	var averageRating sql.NullFloat64
+	err := r.db.QueryRow(`
+		SELECT COUNT(*), COUNT(DISTINCT bp.user_id), AVG(bp.rating)
+		FROM beer_posts bp
+		JOIN users u ON bp.user_id = u.id
+		WHERE `+where, args...).Scan(&stats.PostCount, &stats.VisitorCount, &averageRating)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get venue stats: %w", err)
+	}
+	if averageRating.Valid {
+		stats.AverageRating = &averageRating.Float64
+	}
+
+	if stats.PostCount > 0 {
+		var lastPostAt time.Time
+		err := r.db.QueryRow(`
+			SELECT bp.timestamp
+			FROM beer_posts bp
+			JOIN users u ON bp.user_id = u.id
+			WHERE `+where+`
+			ORDER BY bp.timestamp DESC
+			LIMIT 1`, args...).Scan(&lastPostAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get venue last post: %w", err)
+		}
+		stats.LastPostAt = &lastPostAt
+	}
+
+	return stats, nil
+}
+
+// GetVenueTopVisitors ranks users by their posts at the venue since the given
+// time, counting only posts visible to the viewer. Ties are broken by username.
+func (r *postRepository) GetVenueTopVisitors(venueID string, viewerID string, since time.Time, limit int) ([]models.VenueVisitor, error) {
+	query := `
+		SELECT u.id, u.username, u.profile_image_data, COUNT(*) AS post_count
+		FROM beer_posts bp
+		JOIN users u ON bp.user_id = u.id
+		WHERE bp.venue_id = ? AND bp.timestamp >= ? AND ` + visiblePostFilter + ` AND ` + fmt.Sprintf(hiddenAuthorFilter, "bp.user_id") + `
+		GROUP BY u.id
+		ORDER BY post_count DESC, u.username ASC
+		LIMIT ?
+	`
+
+	rows, err := r.db.Query(query, venueID, since, viewerID, viewerID, viewerID, viewerID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get venue visitors: %w", err)
+	}
+	defer rows.Close()
+
+	visitors := []models.VenueVisitor{}
+	for rows.Next() {
+		var visitor models.VenueVisitor
+		if err := rows.Scan(&visitor.UserID, &visitor.Username, &visitor.UserProfileImageData, &visitor.PostCount); err != nil {
+			return nil, fmt.Errorf("failed to scan venue visitor: %w", err)
+		}
+		visitors = append(visitors, visitor)
+	}
+
+	return visitors, nil
+}
+
+// queryPosts runs a query selecting postSelectColumns and hydrates every
+// resulting post for the viewer.
+func (r *postRepository) queryPosts(query string, viewerID string, args ...interface{}) ([]models.BeerPost, error) {
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		log.Printf("[Repository] ERROR: Query execution failed: %v", err)
-		return nil, 0, fmt.Errorf("failed to get user posts: %w", err)
+		return nil, fmt.Errorf("failed to get posts: %w", err)
 	}
 	defer rows.Close()
 
 	posts := []models.BeerPost{}
-	postCount := 0
 	for rows.Next() {
-		postCount++
 		post := models.BeerPost{}
-		err := rows.Scan(
-			&post.ID, &post.UserID, &post.Username, &post.UserProfileImageData,
-			&post.Caption, &post.ImageData, &post.Location, &post.Timestamp,
-			&post.Upvotes, &post.Downvotes, &post.CreatedAt, &post.UpdatedAt,
-		)
-		if err != nil {
-			log.Printf("[Repository] ERROR: Failed to scan post row %d: %v", postCount, err)
-			return nil, 0, fmt.Errorf("failed to scan post: %w", err)
+		if err := r.scanPost(rows, &post); err != nil {
+			log.Printf("[Repository] ERROR: Failed to scan post row %d: %v", len(posts)+1, err)
+			return nil, fmt.Errorf("failed to scan post: %w", err)
 		}
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get posts: %w", err)
+	}
+	rows.Close()
 
-		// Get comments for each post
-		comments, err := r.GetCommentsByPostID(post.ID)
-		if err != nil {
-			log.Printf("[Repository] ERROR: Failed to get comments for post %s: %v", post.ID, err)
-			return nil, 0, err
+	for i := range posts {
+		if err := r.hydratePost(&posts[i], viewerID); err != nil {
+			log.Printf("[Repository] ERROR: Failed to get comments for post %s: %v", posts[i].ID, err)
+			return nil, err
 		}
-		post.Comments = comments
-
-		// Get user's vote if authenticated
-		if currentUserID != "" {
-			vote, _ := r.GetVoteByUserAndPost(currentUserID, post.ID)
-			if vote != nil {
-				post.HasUserVoted = true
-				post.UserVoteType = &vote.VoteType
-			}
+	}
+
+	return posts, nil
+}
+
+// hydratePost loads the comments visible to the viewer, the reaction counts
+// and the viewer's own vote and reactions on the post.
+func (r *postRepository) hydratePost(post *models.BeerPost, viewerID string) error {
+	comments, err := r.GetCommentsByPostID(post.ID, viewerID)
+	if err != nil {
+		return err
+	}
+	post.Comments = comments
+
+	post.Reactions, post.UserReactions, err = getReactionSummary(r.db, post.ID, viewerID)
+	if err != nil {
+		return err
+	}
+
+	// Get user's vote if authenticated
+	if viewerID != "" {
+		vote, _ := r.GetVoteByUserAndPost(viewerID, post.ID)
+		if vote != nil {
+			post.HasUserVoted = true
+			post.UserVoteType = &vote.VoteType
 		}
+	}
 
-		posts = append(posts, post)
+	return nil
+}
+
+// markRegular sets IsRegular when the author has posted from the post's venue
+// at least venueRegularThreshold times.
+func (r *postRepository) markRegular(post *models.BeerPost) error {
+	post.IsRegular = false
+	if post.VenueID == nil || r.venueRegularThreshold <= 0 {
+		return nil
 	}
 
-	log.Printf("[Repository] GetUserPosts successful - returning %d posts", len(posts))
-	return posts, totalCount, nil
+	var count int
+	err := r.db.QueryRow(
+		`SELECT COUNT(*) FROM beer_posts WHERE venue_id = ? AND user_id = ?`,
+		*post.VenueID, post.UserID,
+	).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to count venue posts: %w", err)
+	}
+	post.IsRegular = r.isRegular(count)
+	return nil
+}
+
+// isRegular reports whether an author with venuePosts posts at a venue is a
+// regular there.
+func (r *postRepository) isRegular(venuePosts int) bool {
+	return r.venueRegularThreshold > 0 && venuePosts >= r.venueRegularThreshold
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// extraColumns scans the columns a query selects after postSelectColumns into
+// dest, so that scanPost can be used for such rows.
+type extraColumns struct {
+	row  rowScanner
+	dest []interface{}
+}
+
+func (e extraColumns) Scan(dest ...interface{}) error {
+	return e.row.Scan(append(dest, e.dest...)...)
+}
+
+// scanPost scans a row selected with postSelectColumns.
+func (r *postRepository) scanPost(row rowScanner, post *models.BeerPost) error {
+	var venuePosts int
+	err := row.Scan(
+		&post.ID, &post.UserID, &post.Username, &post.UserProfileImageData,
+		&post.Caption, &post.ImageData, &post.Location, &post.Timestamp,
+		&post.Upvotes, &post.Downvotes, &post.CreatedAt, &post.UpdatedAt,
+		&post.Visibility, &post.BeerID, &post.BeerName, &post.Brewery, &post.BeerStyle, &post.ABV,
+		&post.ServingType, &post.Rating, &post.Latitude, &post.Longitude, &post.LocationAccuracy,
+		&post.VenueID, &post.VenueName, &venuePosts,
+	)
+	if err != nil {
+		return err
+	}
+	post.IsRegular = post.VenueID != nil && r.isRegular(venuePosts)
+	return nil
 }
 
 func (r *postRepository) GetUserByID(userID string) (*models.User, error) {
-	query := `
-		SELECT id, username, email, profile_image_data, taste_score, 
-		       total_posts, friends_count, joined_date, bio, created_at, updated_at
-		FROM users WHERE id = ?
-	`
+	query := `SELECT ` + userSelectColumns + ` FROM users WHERE id = ?`
 
 	user := &models.User{}
-	err := r.db.QueryRow(query, userID).Scan(
-		&user.ID, &user.Username, &user.Email, &user.ProfileImageData,
-		&user.TasteScore, &user.TotalPosts, &user.FriendsCount,
-		&user.JoinedDate, &user.Bio, &user.CreatedAt, &user.UpdatedAt,
-	)
+	err := scanUser(r.db.QueryRow(query, userID), user)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -326,17 +650,52 @@ func (r *postRepository) CreateOrUpdateUser(user *models.User) error {
 	return err
 }
 
-func (r *postRepository) GetCommentsByPostID(postID string) ([]models.Comment, error) {
+// GetCommentsByPostID returns the post's comments, leaving out comments by
+// users the viewer has blocked or muted.
+func (r *postRepository) GetCommentsByPostID(postID string, viewerID string) ([]models.Comment, error) {
+	query := `
+		SELECT c.id, c.post_id, c.user_id, u.username, u.profile_image_data,
+		       c.text, c.timestamp, c.created_at, c.updated_at
+		FROM comments c
+		JOIN users u ON c.user_id = u.id
+		WHERE c.post_id = ? AND ` + fmt.Sprintf(hiddenAuthorFilter, "c.user_id") + `
+		ORDER BY c.timestamp ASC
+	`
+
+	rows, err := r.db.Query(query, postID, viewerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comments: %w", err)
+	}
+	defer rows.Close()
+
+	comments := []models.Comment{}
+	for rows.Next() {
+		comment := models.Comment{}
+		err := rows.Scan(
+			&comment.ID, &comment.PostID, &comment.UserID, &comment.Username,
+			&comment.UserProfileImageData, &comment.Text, &comment.Timestamp,
+			&comment.CreatedAt, &comment.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan comment: %w", err)
+		}
+		comments = append(comments, comment)
+	}
+
+	return comments, nil
+}
+
+func (r *postRepository) GetCommentsByUserID(userID string) ([]models.Comment, error) {
 	query := `
 		SELECT c.id, c.post_id, c.user_id, u.username, u.profile_image_data,
 		       c.text, c.timestamp, c.created_at, c.updated_at
 		FROM comments c
 		JOIN users u ON c.user_id = u.id
-		WHERE c.post_id = ?
+		WHERE c.user_id = ?
 		ORDER BY c.timestamp ASC
 	`
 
-	rows, err := r.db.Query(query, postID)
+	rows, err := r.db.Query(query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get comments: %w", err)
 	}
@@ -359,6 +718,119 @@ func (r *postRepository) GetCommentsByPostID(postID string) ([]models.Comment, e
 	return comments, nil
 }
 
+func (r *postRepository) GetVotesByUserID(userID string) ([]models.Vote, error) {
+	query := `SELECT id, post_id, user_id, vote_type, created_at, updated_at FROM votes WHERE user_id = ? ORDER BY created_at ASC`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get votes: %w", err)
+	}
+	defer rows.Close()
+
+	votes := []models.Vote{}
+	for rows.Next() {
+		vote := models.Vote{}
+		err := rows.Scan(
+			&vote.ID, &vote.PostID, &vote.UserID, &vote.VoteType,
+			&vote.CreatedAt, &vote.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan vote: %w", err)
+		}
+		votes = append(votes, vote)
+	}
+
+	return votes, nil
+}
+
+func (r *postRepository) GetPostVoters(postID, viewerID string, voteType models.VoteType, before *VoteCursor, limit int) ([]models.Voter, error) {
+	query := `
+		SELECT v.id, v.user_id, u.username, u.profile_image_data, v.vote_type, v.updated_at
+		FROM votes v
+		JOIN users u ON v.user_id = u.id
+		WHERE v.post_id = ? AND ` + fmt.Sprintf(hiddenAuthorFilter, "v.user_id")
+	args := []interface{}{postID, viewerID}
+	if voteType != "" {
+		query += ` AND v.vote_type = ?`
+		args = append(args, voteType)
+	}
+	if before != nil {
+		query += ` AND (v.updated_at < ? OR (v.updated_at = ? AND v.id < ?))`
+		votedAt := before.VotedAt.UTC()
+		args = append(args, votedAt, votedAt, before.ID)
+	}
+	query += `
+		ORDER BY v.updated_at DESC, v.id DESC
+		LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get voters: %w", err)
+	}
+	defer rows.Close()
+
+	voters := []models.Voter{}
+	for rows.Next() {
+		var voter models.Voter
+		err := rows.Scan(&voter.VoteID, &voter.UserID, &voter.Username, &voter.UserProfileImageData, &voter.VoteType, &voter.VotedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan voter: %w", err)
+		}
+		voters = append(voters, voter)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get voters: %w", err)
+	}
+	return voters, nil
+}
+
+func (r *postRepository) GetVotedPosts(userID string, before *VoteCursor, limit int) ([]models.VotedPost, error) {
+	query := `
+		SELECT ` + postSelectColumns + `, v.id, v.vote_type, v.updated_at
+		FROM votes v
+		JOIN beer_posts bp ON v.post_id = bp.id
+		JOIN users u ON bp.user_id = u.id
+		WHERE v.user_id = ? AND ` + visiblePostFilter
+	args := []interface{}{userID, userID, userID, userID}
+	if before != nil {
+		query += ` AND (v.updated_at < ? OR (v.updated_at = ? AND v.id < ?))`
+		votedAt := before.VotedAt.UTC()
+		args = append(args, votedAt, votedAt, before.ID)
+	}
+	query += `
+		ORDER BY v.updated_at DESC, v.id DESC
+		LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get voted posts: %w", err)
+	}
+	defer rows.Close()
+
+	votedPosts := []models.VotedPost{}
+	for rows.Next() {
+		var votedPost models.VotedPost
+		vote := extraColumns{rows, []interface{}{&votedPost.VoteID, &votedPost.VoteType, &votedPost.VotedAt}}
+		if err := r.scanPost(vote, &votedPost.Post); err != nil {
+			return nil, fmt.Errorf("failed to scan voted post: %w", err)
+		}
+		votedPosts = append(votedPosts, votedPost)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get voted posts: %w", err)
+	}
+	rows.Close()
+
+	for i := range votedPosts {
+		if err := r.hydratePost(&votedPosts[i].Post, userID); err != nil {
+			return nil, err
+		}
+	}
+	return votedPosts, nil
+}
+
 func (r *postRepository) GetVoteByUserAndPost(userID, postID string) (*models.Vote, error) {
 	query := `SELECT id, post_id, user_id, vote_type, created_at, updated_at FROM votes WHERE user_id = ? AND post_id = ?`
 
@@ -378,46 +850,59 @@ func (r *postRepository) GetVoteByUserAndPost(userID, postID string) (*models.Vo
 	return vote, nil
 }
 
-func (r *postRepository) AddVote(vote *models.Vote) error {
-	query := `
-		INSERT INTO votes (id, post_id, user_id, vote_type, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`
-	_, err := r.db.Exec(query, vote.ID, vote.PostID, vote.UserID, vote.VoteType, vote.CreatedAt, vote.UpdatedAt)
+func (r *postRepository) SaveVote(vote *models.Vote, previous models.VoteType, removed bool, upvoteChange, downvoteChange int, outbox []models.OutboxMessage) (int, int, bool, error) {
+	tx, err := r.db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to add vote: %w", err)
+		return 0, 0, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Each write is conditional on the vote read before the transaction, so a
+	// repeated request, e.g. a double tap, can't apply the same change twice
+	var result sql.Result
+	switch {
+	case removed:
+		result, err = tx.Exec(`DELETE FROM votes WHERE id = ? AND vote_type = ?`, vote.ID, previous)
+	case previous != "":
+		result, err = tx.Exec(
+			`UPDATE votes SET vote_type = ?, updated_at = ? WHERE id = ? AND vote_type = ?`,
+			vote.VoteType, vote.UpdatedAt, vote.ID, previous,
+		)
+	default:
+		result, err = tx.Exec(`
+			INSERT INTO votes (id, post_id, user_id, vote_type, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT DO NOTHING
+		`, vote.ID, vote.PostID, vote.UserID, vote.VoteType, vote.CreatedAt, vote.UpdatedAt)
 	}
-	return nil
-}
-
-func (r *postRepository) UpdateVote(vote *models.Vote) error {
-	query := `
-		UPDATE votes SET vote_type = ?, updated_at = ?
-		WHERE id = ?
-	`
-	_, err := r.db.Exec(query, vote.VoteType, vote.UpdatedAt, vote.ID)
 	if err != nil {
-		return fmt.Errorf("failed to update vote: %w", err)
+		return 0, 0, false, fmt.Errorf("failed to save vote: %w", err)
 	}
-	return nil
-}
-
-func (r *postRepository) DeleteVote(voteID string) error {
-	query := `DELETE FROM votes WHERE id = ?`
-	_, err := r.db.Exec(query, voteID)
+	changed, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to delete vote: %w", err)
+		return 0, 0, false, fmt.Errorf("failed to save vote: %w", err)
 	}
-	return nil
-}
 
-func (r *postRepository) UpdatePostVotes(postID string, upvotes, downvotes int) error {
-	query := `UPDATE beer_posts SET upvotes = ?, downvotes = ? WHERE id = ?`
-	_, err := r.db.Exec(query, upvotes, downvotes, postID)
-	if err != nil {
-		return fmt.Errorf("failed to update post votes: %w", err)
+	if changed > 0 {
+		_, err = tx.Exec(`UPDATE beer_posts SET upvotes = upvotes + ?, downvotes = downvotes + ? WHERE id = ?`,
+			upvoteChange, downvoteChange, vote.PostID)
+		if err != nil {
+			return 0, 0, false, fmt.Errorf("failed to update post votes: %w", err)
+		}
+		if err := insertOutboxMessages(tx, outbox); err != nil {
+			return 0, 0, false, err
+		}
 	}
-	return nil
+
+	var upvotes, downvotes int
+	if err := tx.QueryRow(`SELECT upvotes, downvotes FROM beer_posts WHERE id = ?`, vote.PostID).Scan(&upvotes, &downvotes); err != nil {
+		return 0, 0, false, fmt.Errorf("failed to get post votes: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, false, fmt.Errorf("failed to commit vote: %w", err)
+	}
+	return upvotes, downvotes, changed > 0, nil
 }
 
 func (r *postRepository) AddComment(comment *models.Comment) error {
@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/batku/beerreal/internal/models"
+	"github.com/google/uuid"
+)
+
+const realMojiSelectColumns = `rm.id, rm.post_id, rm.user_id, u.username, u.profile_image_data, rm.image_data, rm.created_at`
+
+type ReactionRepository interface {
+	// AddReaction stores the reaction; adding one that exists is a no-op.
+	AddReaction(postID, userID string, reactionType models.ReactionType) error
+	RemoveReaction(postID, userID string, reactionType models.ReactionType) error
+	// GetReactions lists who reacted to the post, newest first, leaving out
+	// users the viewer has blocked or muted. An empty reactionType lists all.
+	GetReactions(postID, viewerID string, reactionType models.ReactionType, limit, offset int) ([]models.Reaction, int, error)
+	GetReactionsByUserID(userID string) ([]models.Reaction, error)
+	GetReactionSummary(postID, viewerID string) (*models.PostReactionsResponse, error)
+
+	// SaveRealMoji stores the user's RealMoji on the post, replacing an
+	// earlier one.
+	SaveRealMoji(realMoji *models.RealMoji) error
+	GetRealMojiByID(id string) (*models.RealMoji, error)
+	// GetRealMojis lists the post's RealMojis, newest first, leaving out
+	// users the viewer has blocked or muted.
+	GetRealMojis(postID, viewerID string, limit, offset int) ([]models.RealMoji, int, error)
+	GetRealMojisByUserID(userID string) ([]models.RealMoji, error)
+	DeleteRealMoji(id string) error
+}
+
+type reactionRepository struct {
+	db *sql.DB
+}
+
+func NewReactionRepository(db *sql.DB) ReactionRepository {
+	return &reactionRepository{db: db}
+}
+
+func (r *reactionRepository) AddReaction(postID, userID string, reactionType models.ReactionType) error {
+	_, err := r.db.Exec(`
+		INSERT OR IGNORE INTO reactions (post_id, user_id, type, created_at)
+		VALUES (?, ?, ?, ?)
+	`, postID, userID, reactionType, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to add reaction: %w", err)
+	}
+	return nil
+}
+
+func (r *reactionRepository) RemoveReaction(postID, userID string, reactionType models.ReactionType) error {
+	_, err := r.db.Exec(`DELETE FROM reactions WHERE post_id = ? AND user_id = ? AND type = ?`, postID, userID, reactionType)
+	if err != nil {
+		return fmt.Errorf("failed to remove reaction: %w", err)
+	}
+	return nil
+}
+
+func (r *reactionRepository) GetReactions(postID, viewerID string, reactionType models.ReactionType, limit, offset int) ([]models.Reaction, int, error) {
+	where := `re.post_id = ? AND ` + fmt.Sprintf(hiddenAuthorFilter, "re.user_id")
+	args := []interface{}{postID, viewerID}
+	if reactionType != "" {
+		where += ` AND re.type = ?`
+		args = append(args, reactionType)
+	}
+
+	var totalCount int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM reactions re WHERE `+where, args...).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count reactions: %w", err)
+	}
+
+	rows, err := r.db.Query(`
+		SELECT re.post_id, re.user_id, u.username, u.profile_image_data, re.type, re.created_at
+		FROM reactions re
+		JOIN users u ON re.user_id = u.id
+		WHERE `+where+`
+		ORDER BY re.created_at DESC, re.user_id ASC
+		LIMIT ? OFFSET ?
+	`, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get reactions: %w", err)
+	}
+	defer rows.Close()
+
+	reactions, err := scanReactions(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return reactions, totalCount, nil
+}
+
+func (r *reactionRepository) GetReactionsByUserID(userID string) ([]models.Reaction, error) {
+	rows, err := r.db.Query(`
+		SELECT re.post_id, re.user_id, u.username, u.profile_image_data, re.type, re.created_at
+		FROM reactions re
+		JOIN users u ON re.user_id = u.id
+		WHERE re.user_id = ?
+		ORDER BY re.created_at ASC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reactions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanReactions(rows)
+}
+
+func (r *reactionRepository) GetReactionSummary(postID, viewerID string) (*models.PostReactionsResponse, error) {
+	counts, own, err := getReactionSummary(r.db, postID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.PostReactionsResponse{Reactions: counts, UserReactions: own}, nil
+}
+
+// getReactionSummary returns the post's reaction counts by type and the
+// viewer's own reactions; both are empty rather than nil. Like the reaction
+// list, the counts leave out users the viewer blocked or muted.
+func getReactionSummary(db *sql.DB, postID, viewerID string) (map[models.ReactionType]int, []models.ReactionType, error) {
+	rows, err := db.Query(`
+		SELECT re.type, COUNT(*), COALESCE(MAX(re.user_id = ?), 0)
+		FROM reactions re
+		WHERE re.post_id = ? AND `+fmt.Sprintf(hiddenAuthorFilter, "re.user_id")+`
+		GROUP BY re.type
+		ORDER BY re.type
+	`, viewerID, postID, viewerID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get reaction counts: %w", err)
+	}
+	defer rows.Close()
+
+	counts := map[models.ReactionType]int{}
+	own := []models.ReactionType{}
+	for rows.Next() {
+		var reactionType models.ReactionType
+		var count int
+		var reacted bool
+		if err := rows.Scan(&reactionType, &count, &reacted); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan reaction count: %w", err)
+		}
+		counts[reactionType] = count
+		if reacted {
+			own = append(own, reactionType)
+		}
+	}
+	return counts, own, nil
+}
+
+func scanReactions(rows *sql.Rows) ([]models.Reaction, error) {
+	reactions := []models.Reaction{}
+	for rows.Next() {
+		var reaction models.Reaction
+		err := rows.Scan(
+			&reaction.PostID, &reaction.UserID, &reaction.Username, &reaction.UserProfileImageData,
+			&reaction.Type, &reaction.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reaction: %w", err)
+		}
+		reactions = append(reactions, reaction)
+	}
+	return reactions, nil
+}
+
+func (r *reactionRepository) SaveRealMoji(realMoji *models.RealMoji) error {
+	realMoji.ID = uuid.New().String()
+	realMoji.CreatedAt = time.Now().UTC()
+	_, err := r.db.Exec(`
+		INSERT INTO realmojis (id, post_id, user_id, image_data, created_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(post_id, user_id) DO UPDATE SET
+			id = excluded.id,
+			image_data = excluded.image_data,
+			created_at = excluded.created_at
+	`, realMoji.ID, realMoji.PostID, realMoji.UserID, realMoji.ImageData, realMoji.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save RealMoji: %w", err)
+	}
+	return nil
+}
+
+// GetRealMojiByID returns the RealMoji, or nil if it does not exist.
+func (r *reactionRepository) GetRealMojiByID(id string) (*models.RealMoji, error) {
+	rows, err := r.db.Query(`
+		SELECT `+realMojiSelectColumns+`
+		FROM realmojis rm
+		JOIN users u ON rm.user_id = u.id
+		WHERE rm.id = ?
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get RealMoji: %w", err)
+	}
+	defer rows.Close()
+
+	realMojis, err := scanRealMojis(rows)
+	if err != nil || len(realMojis) == 0 {
+		return nil, err
+	}
+	return &realMojis[0], nil
+}
+
+func (r *reactionRepository) GetRealMojis(postID, viewerID string, limit, offset int) ([]models.RealMoji, int, error) {
+	where := `rm.post_id = ? AND ` + fmt.Sprintf(hiddenAuthorFilter, "rm.user_id")
+	args := []interface{}{postID, viewerID}
+
+	var totalCount int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM realmojis rm WHERE `+where, args...).Scan(&totalCount); err != nil {
+		return nil, 0, fmt.Errorf("failed to count RealMojis: %w", err)
+	}
+
+	rows, err := r.db.Query(`
+		SELECT `+realMojiSelectColumns+`
+		FROM realmojis rm
+		JOIN users u ON rm.user_id = u.id
+		WHERE `+where+`
+		ORDER BY rm.created_at DESC, rm.id ASC
+		LIMIT ? OFFSET ?
+	`, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get RealMojis: %w", err)
+	}
+	defer rows.Close()
+
+	realMojis, err := scanRealMojis(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return realMojis, totalCount, nil
+}
+
+func (r *reactionRepository) GetRealMojisByUserID(userID string) ([]models.RealMoji, error) {
+	rows, err := r.db.Query(`
+		SELECT `+realMojiSelectColumns+`
+		FROM realmojis rm
+		JOIN users u ON rm.user_id = u.id
+		WHERE rm.user_id = ?
+		ORDER BY rm.created_at ASC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get RealMojis: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRealMojis(rows)
+}
+
+func (r *reactionRepository) DeleteRealMoji(id string) error {
+	if _, err := r.db.Exec(`DELETE FROM realmojis WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete RealMoji: %w", err)
+	}
+	return nil
+}
+
+func scanRealMojis(rows *sql.Rows) ([]models.RealMoji, error) {
+	realMojis := []models.RealMoji{}
+	for rows.Next() {
+		var realMoji models.RealMoji
+		err := rows.Scan(
+			&realMoji.ID, &realMoji.PostID, &realMoji.UserID, &realMoji.Username, &realMoji.UserProfileImageData,
+			&realMoji.ImageData, &realMoji.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan RealMoji: %w", err)
+		}
+		realMojis = append(realMojis, realMoji)
+	}
+	return realMojis, nil
+}
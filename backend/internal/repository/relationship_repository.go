@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/batku/beerreal/internal/models"
+)
+
+type RelationshipRepository interface {
+	SetRestriction(restriction *models.UserRestriction) error
+	RemoveRestriction(userID, targetUserID string, restrictionType models.RestrictionType) error
+	GetRestriction(userID, targetUserID string) (*models.UserRestriction, error)
+	GetRestrictionsByUserID(userID string, restrictionType models.RestrictionType) ([]models.UserRestriction, error)
+	GetFriendship(userID, otherUserID string) (*models.Friendship, error)
+	CreateFriendRequest(friendship *models.Friendship) error
+	AcceptFriendRequest(requesterID, addresseeID string) error
+	DeleteFriendship(userID, otherUserID string) error
+	GetFriends(userID string) ([]models.User, error)
+	GetIncomingFriendRequests(userID string) ([]models.Friendship, error)
+}
+
+type relationshipRepository struct {
+	db *sql.DB
+}
+
+func NewRelationshipRepository(db *sql.DB) RelationshipRepository {
+	return &relationshipRepository{db: db}
+}
+
+// SetRestriction creates the restriction or replaces an existing one between
+// the same two users, so blocking a muted user upgrades the mute to a block.
+// A mute never replaces a block, which would silently lift it.
+func (r *relationshipRepository) SetRestriction(restriction *models.UserRestriction) error {
+	query := `
+		INSERT INTO user_restrictions (user_id, target_user_id, restriction_type, created_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(user_id, target_user_id) DO UPDATE SET
+			restriction_type = excluded.restriction_type,
+			created_at = excluded.created_at
+		WHERE user_restrictions.restriction_type <> 'BLOCK' OR excluded.restriction_type = 'BLOCK'
+	`
+	if restriction.CreatedAt.IsZero() {
+		restriction.CreatedAt = time.Now()
+	}
+	_, err := r.db.Exec(query, restriction.UserID, restriction.TargetUserID, restriction.Type, restriction.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to set restriction: %w", err)
+	}
+	return nil
+}
+
+func (r *relationshipRepository) RemoveRestriction(userID, targetUserID string, restrictionType models.RestrictionType) error {
+	query := `DELETE FROM user_restrictions WHERE user_id = ? AND target_user_id = ? AND restriction_type = ?`
+	_, err := r.db.Exec(query, userID, targetUserID, restrictionType)
+	if err != nil {
+		return fmt.Errorf("failed to remove restriction: %w", err)
+	}
+	return nil
+}
+
+func (r *relationshipRepository) GetRestriction(userID, targetUserID string) (*models.UserRestriction, error) {
+	query := `
+		SELECT user_id, target_user_id, restriction_type, created_at
+		FROM user_restrictions
+		WHERE user_id = ? AND target_user_id = ?
+	`
+
+	restriction := &models.UserRestriction{}
+	err := r.db.QueryRow(query, userID, targetUserID).Scan(
+		&restriction.UserID, &restriction.TargetUserID, &restriction.Type, &restriction.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get restriction: %w", err)
+	}
+
+	return restriction, nil
+}
+
+// GetRestrictionsByUserID lists the restrictions created by the user. An empty
+// restrictionType returns both blocks and mutes.
+func (r *relationshipRepository) GetRestrictionsByUserID(userID string, restrictionType models.RestrictionType) ([]models.UserRestriction, error) {
+	query := `
+		SELECT user_id, target_user_id, restriction_type, created_at
+		FROM user_restrictions
+		WHERE user_id = ? AND (? = '' OR restriction_type = ?)
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Query(query, userID, restrictionType, restrictionType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get restrictions: %w", err)
+	}
+	defer rows.Close()
+
+	restrictions := []models.UserRestriction{}
+	for rows.Next() {
+		restriction := models.UserRestriction{}
+		err := rows.Scan(&restriction.UserID, &restriction.TargetUserID, &restriction.Type, &restriction.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan restriction: %w", err)
+		}
+		restrictions = append(restrictions, restriction)
+	}
+
+	return restrictions, nil
+}
+
+// GetFriendship returns the friendship between the two users in either
+// direction, or nil if there is none.
+func (r *relationshipRepository) GetFriendship(userID, otherUserID string) (*models.Friendship, error) {
+	query := `
+		SELECT user_id, friend_id, status, created_at, updated_at
+		FROM friendships
+		WHERE (user_id = ? AND friend_id = ?) OR (user_id = ? AND friend_id = ?)
+	`
+
+	friendship := &models.Friendship{}
+	err := r.db.QueryRow(query, userID, otherUserID, otherUserID, userID).Scan(
+		&friendship.UserID, &friendship.FriendID, &friendship.Status,
+		&friendship.CreatedAt, &friendship.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get friendship: %w", err)
+	}
+
+	return friendship, nil
+}
+
+func (r *relationshipRepository) CreateFriendRequest(friendship *models.Friendship) error {
+	query := `
+		INSERT INTO friendships (user_id, friend_id, status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	_, err := r.db.Exec(query, friendship.UserID, friendship.FriendID, friendship.Status,
+		friendship.CreatedAt, friendship.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create friend request: %w", err)
+	}
+	return nil
+}
+
+// AcceptFriendRequest marks a pending request as accepted and increments both
+// users' friend counters.
+func (r *relationshipRepository) AcceptFriendRequest(requesterID, addresseeID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result, err := tx.Exec(`
+		UPDATE friendships SET status = 'ACCEPTED', updated_at = ?
+		WHERE user_id = ? AND friend_id = ? AND status = 'PENDING'
+	`, now, requesterID, addresseeID)
+	if err != nil {
+		return fmt.Errorf("failed to accept friend request: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return tx.Commit()
+	}
+
+	_, err = tx.Exec(`UPDATE users SET friends_count = friends_count + 1, updated_at = ? WHERE id IN (?, ?)`,
+		now, requesterID, addresseeID)
+	if err != nil {
+		return fmt.Errorf("failed to update friend counts: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// DeleteFriendship removes a pending request or an accepted friendship in
+// either direction, decrementing friend counters if it was accepted.
+func (r *relationshipRepository) DeleteFriendship(userID, otherUserID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status models.FriendshipStatus
+	err = tx.QueryRow(`
+		SELECT status FROM friendships
+		WHERE (user_id = ? AND friend_id = ?) OR (user_id = ? AND friend_id = ?)
+	`, userID, otherUserID, otherUserID, userID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("failed to get friendship: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM friendships
+		WHERE (user_id = ? AND friend_id = ?) OR (user_id = ? AND friend_id = ?)
+	`, userID, otherUserID, otherUserID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete friendship: %w", err)
+	}
+
+	if status == models.FriendshipStatusAccepted {
+		_, err = tx.Exec(`UPDATE users SET friends_count = MAX(friends_count - 1, 0), updated_at = ? WHERE id IN (?, ?)`,
+			time.Now(), userID, otherUserID)
+		if err != nil {
+			return fmt.Errorf("failed to update friend counts: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *relationshipRepository) GetFriends(userID string) ([]models.User, error) {
+	query := `
+		SELECT ` + userSelectColumns + `
+		FROM users
+		WHERE id IN (
+			SELECT friend_id FROM friendships WHERE user_id = ? AND status = 'ACCEPTED'
+			UNION
+			SELECT user_id FROM friendships WHERE friend_id = ? AND status = 'ACCEPTED'
+		)
+		ORDER BY username ASC
+	`
+
+	rows, err := r.db.Query(query, userID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get friends: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var user models.User
+		if err := scanUser(rows, &user); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+func (r *relationshipRepository) GetIncomingFriendRequests(userID string) ([]models.Friendship, error) {
+	query := `
+		SELECT user_id, friend_id, status, created_at, updated_at
+		FROM friendships
+		WHERE friend_id = ? AND status = 'PENDING'
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get friend requests: %w", err)
+	}
+	defer rows.Close()
+
+	requests := []models.Friendship{}
+	for rows.Next() {
+		friendship := models.Friendship{}
+		err := rows.Scan(&friendship.UserID, &friendship.FriendID, &friendship.Status,
+			&friendship.CreatedAt, &friendship.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan friend request: %w", err)
+		}
+		requests = append(requests, friendship)
+	}
+
+	return requests, nil
+}
@@ -3,15 +3,51 @@ package repository
 import (
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/batku/beerreal/internal/models"
+	"github.com/google/uuid"
 )
 
 type UserRepository interface {
 	GetUserByID(id string) (*models.User, error)
+	GetUserByUsername(username string) (*models.User, error)
 	CreateOrUpdateUser(user *models.User) error
 	UpdateUser(user *models.User) error
-	UpdateTasteScore(userID string, scoreChange int) error
+	RecordTasteScoreEvent(event *models.TasteScoreEvent) error
+	GetTasteScoreEvents(userID string, since time.Time) ([]models.TasteScoreEvent, error)
+	GetTasteScoreByPost(userID string, since time.Time) ([]models.PostScoreContribution, error)
+	SearchUsers(prefix string, limit, offset int) ([]models.User, int, error)
+	DeleteUser(userID string) error
+	UpdateStreak(streak *models.UserStreak) error
+	GetActiveStreaks() ([]models.UserStreak, error)
+	ResetStreaks(userIDs []string) error
+	GetTopScores(viewerID string, friendsOnly bool, since time.Time, limit int) ([]models.UserScore, error)
+}
+
+// userSelectColumns is the column list of the users table; keep it in sync
+// with scanUser.
+const userSelectColumns = `id, username, email, profile_image_data, taste_score, total_posts, friends_count, joined_date, bio, is_private,
+	time_zone, current_streak, longest_streak, last_post_date, created_at, updated_at`
+
+func scanUser(row rowScanner, user *models.User) error {
+	return row.Scan(
+		&user.ID, &user.Username, &user.Email, &user.ProfileImageData,
+		&user.TasteScore, &user.TotalPosts, &user.FriendsCount,
+		&user.JoinedDate, &user.Bio, &user.IsPrivate,
+		&user.TimeZone, &user.CurrentStreak, &user.LongestStreak, &user.LastPostDate,
+		&user.CreatedAt, &user.UpdatedAt,
+	)
+}
+
+// likeEscaper escapes LIKE wildcards; queries using it must declare ESCAPE '\'.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike makes s match literally inside a LIKE pattern.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
 }
 
 type userRepository struct {
@@ -24,18 +60,34 @@ func NewUserRepository(db *sql.DB) UserRepository {
 
 func (r *userRepository) GetUserByID(id string) (*models.User, error) {
 	query := `
-		SELECT id, username, email, profile_image_data, taste_score, total_posts, friends_count, joined_date, bio, created_at, updated_at
+		SELECT ` + userSelectColumns + `
 		FROM users
 		WHERE id = ?
 	`
 	row := r.db.QueryRow(query, id)
 
 	var user models.User
-	err := row.Scan(
-		&user.ID, &user.Username, &user.Email, &user.ProfileImageData,
-		&user.TasteScore, &user.TotalPosts, &user.FriendsCount,
-		&user.JoinedDate, &user.Bio, &user.CreatedAt, &user.UpdatedAt,
-	)
+	err := scanUser(row, &user)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (r *userRepository) GetUserByUsername(username string) (*models.User, error) {
+	query := `
+		SELECT ` + userSelectColumns + `
+		FROM users
+		WHERE username = ? COLLATE NOCASE
+	`
+	row := r.db.QueryRow(query, username)
+
+	var user models.User
+	err := scanUser(row, &user)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -67,21 +119,394 @@ func (r *userRepository) CreateOrUpdateUser(user *models.User) error {
 func (r *userRepository) UpdateUser(user *models.User) error {
 	query := `
 		UPDATE users
-		SET username = ?, profile_image_data = ?, bio = ?, updated_at = ?
+		SET username = ?, profile_image_data = ?, bio = ?, is_private = ?, time_zone = ?, updated_at = ?
 		WHERE id = ?
 	`
 	_, err := r.db.Exec(query,
-		user.Username, user.ProfileImageData, user.Bio, user.UpdatedAt, user.ID,
+		user.Username, user.ProfileImageData, user.Bio, user.IsPrivate, user.TimeZone, user.UpdatedAt, user.ID,
 	)
 	return err
 }
 
-func (r *userRepository) UpdateTasteScore(userID string, scoreChange int) error {
+// RecordTasteScoreEvent stores the event and applies its delta to the user's
+// taste score in one transaction. An event whose outbox message was already
+// applied, or for a user who no longer exists, is skipped.
+func (r *userRepository) RecordTasteScoreEvent(event *models.TasteScoreEvent) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	event.ID = uuid.New().String()
+	event.CreatedAt = time.Now().UTC()
+	result, err := tx.Exec(`
+		INSERT INTO taste_score_events (id, user_id, post_id, voter_id, vote_id, delta, created_at, outbox_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(outbox_id) DO NOTHING
+	`, event.ID, event.UserID, event.PostID, event.VoterID, event.VoteID, event.Delta, event.CreatedAt, event.OutboxID)
+	if err != nil {
+		return fmt.Errorf("failed to record taste score event: %w", err)
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to record taste score event: %w", err)
+	}
+	if inserted == 0 {
+		// Already applied by an earlier delivery of the outbox message
+		return nil
+	}
+
+	result, err = tx.Exec(`UPDATE users SET taste_score = taste_score + ? WHERE id = ?`, event.Delta, event.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to update taste score: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update taste score: %w", err)
+	}
+	if updated == 0 {
+		// The user deleted their account in the meantime
+		return nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit taste score event: %w", err)
+	}
+	return nil
+}
+
+// GetTasteScoreEvents returns the user's taste score events since the given
+// time, oldest first. Events are stored in UTC, so since is compared in UTC.
+func (r *userRepository) GetTasteScoreEvents(userID string, since time.Time) ([]models.TasteScoreEvent, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, post_id, voter_id, vote_id, delta, created_at
+		FROM taste_score_events
+		WHERE user_id = ? AND created_at >= ?
+		ORDER BY created_at ASC
+	`, userID, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get taste score events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []models.TasteScoreEvent{}
+	for rows.Next() {
+		var event models.TasteScoreEvent
+		if err := rows.Scan(&event.ID, &event.UserID, &event.PostID, &event.VoterID, &event.VoteID, &event.Delta, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan taste score event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// GetTasteScoreByPost sums the user's taste score events since the given time
+// per post, largest contribution first.
+func (r *userRepository) GetTasteScoreByPost(userID string, since time.Time) ([]models.PostScoreContribution, error) {
+	rows, err := r.db.Query(`
+		SELECT bp.id, bp.caption, bp.beer_name, SUM(e.delta) AS delta, bp.upvotes, bp.downvotes
+		FROM taste_score_events e
+		JOIN beer_posts bp ON e.post_id = bp.id
+		WHERE e.user_id = ? AND e.created_at >= ?
+		GROUP BY bp.id
+		ORDER BY delta DESC, bp.timestamp DESC
+	`, userID, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get taste score by post: %w", err)
+	}
+	defer rows.Close()
+
+	contributions := []models.PostScoreContribution{}
+	for rows.Next() {
+		var c models.PostScoreContribution
+		if err := rows.Scan(&c.PostID, &c.Caption, &c.BeerName, &c.Delta, &c.Upvotes, &c.Downvotes); err != nil {
+			return nil, fmt.Errorf("failed to scan taste score contribution: %w", err)
+		}
+		contributions = append(contributions, c)
+	}
+
+	return contributions, nil
+}
+
+func (r *userRepository) UpdateStreak(streak *models.UserStreak) error {
 	query := `
 		UPDATE users
-		SET taste_score = taste_score + ?
+		SET current_streak = ?, longest_streak = ?, last_post_date = ?
 		WHERE id = ?
 	`
-	_, err := r.db.Exec(query, scoreChange, userID)
-	return err
+	if _, err := r.db.Exec(query, streak.CurrentStreak, streak.LongestStreak, streak.LastPostDate, streak.UserID); err != nil {
+		return fmt.Errorf("failed to update streak: %w", err)
+	}
+	return nil
+}
+
+// GetActiveStreaks returns the streak state of every user with a current
+// streak.
+func (r *userRepository) GetActiveStreaks() ([]models.UserStreak, error) {
+	rows, err := r.db.Query(`
+		SELECT id, time_zone, current_streak, longest_streak, last_post_date
+		FROM users
+		WHERE current_streak > 0
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get streaks: %w", err)
+	}
+	defer rows.Close()
+
+	streaks := []models.UserStreak{}
+	for rows.Next() {
+		var streak models.UserStreak
+		if err := rows.Scan(&streak.UserID, &streak.TimeZone, &streak.CurrentStreak, &streak.LongestStreak, &streak.LastPostDate); err != nil {
+			return nil, fmt.Errorf("failed to scan streak: %w", err)
+		}
+		streaks = append(streaks, streak)
+	}
+
+	return streaks, nil
+}
+
+// ResetStreaks sets the current streak of the users to zero.
+func (r *userRepository) ResetStreaks(userIDs []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, userID := range userIDs {
+		if _, err := tx.Exec(`UPDATE users SET current_streak = 0 WHERE id = ?`, userID); err != nil {
+			return fmt.Errorf("failed to reset streak: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit streak reset: %w", err)
+	}
+	return nil
+}
+
+func (r *userRepository) SearchUsers(prefix string, limit, offset int) ([]models.User, int, error) {
+	pattern := escapeLike(prefix) + "%"
+
+	var totalCount int
+	countQuery := `SELECT COUNT(*) FROM users WHERE username LIKE ? ESCAPE '\'`
+	if err := r.db.QueryRow(countQuery, pattern).Scan(&totalCount); err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	query := `
+		SELECT ` + userSelectColumns + `
+		FROM users
+		WHERE username LIKE ? ESCAPE '\'
+		ORDER BY username ASC
+		LIMIT ? OFFSET ?
+	`
+	rows, err := r.db.Query(query, pattern, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var user models.User
+		err := scanUser(rows, &user)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	return users, totalCount, nil
+}
+
+// DeleteUser removes the user together with their posts, comments, votes,
+// friendships and blocks/mutes.
+// Votes the user cast on other people's posts are reversed first so that vote
+// counters and the authors' taste scores stay consistent.
+func (r *userRepository) DeleteUser(userID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	statements := []struct {
+		query string
+		args  []interface{}
+	}{
+		{
+			// Reverse vote counters on other users' posts
+			query: `
+				UPDATE beer_posts
+				SET upvotes = upvotes - (SELECT COUNT(*) FROM votes v WHERE v.post_id = beer_posts.id AND v.user_id = ? AND v.vote_type = 'UPVOTE'),
+				    downvotes = downvotes - (SELECT COUNT(*) FROM votes v WHERE v.post_id = beer_posts.id AND v.user_id = ? AND v.vote_type = 'DOWNVOTE')
+				WHERE user_id != ? AND id IN (SELECT post_id FROM votes WHERE user_id = ?)
+			`,
+			args: []interface{}{userID, userID, userID, userID},
+		},
+		{
+			// Reverse the taste score the user's votes gave to other authors,
+			// as far as it has been applied
+			query: `
+				UPDATE users
+				SET taste_score = taste_score - (
+					SELECT COALESCE(SUM(e.delta), 0)
+					FROM taste_score_events e
+					WHERE e.voter_id = ? AND e.user_id = users.id
+				)
+				WHERE id != ? AND id IN (SELECT user_id FROM taste_score_events WHERE voter_id = ?)
+			`,
+			args: []interface{}{userID, userID, userID},
+		},
+		{
+			// Drop taste score changes from the user's votes that are not
+			// applied yet
+			query: `DELETE FROM outbox WHERE type = ? AND json_extract(payload, '$.voterId') = ?`,
+			args:  []interface{}{models.OutboxTypeVoteCast, userID},
+		},
+		{
+			// Drop the user from their friends' counters
+			query: `
+				UPDATE users
+				SET friends_count = MAX(friends_count - 1, 0)
+				WHERE id IN (
+					SELECT friend_id FROM friendships WHERE user_id = ? AND status = 'ACCEPTED'
+					UNION
+					SELECT user_id FROM friendships WHERE friend_id = ? AND status = 'ACCEPTED'
+				)
+			`,
+			args: []interface{}{userID, userID},
+		},
+		{
+			query: `DELETE FROM friendships WHERE user_id = ? OR friend_id = ?`,
+			args:  []interface{}{userID, userID},
+		},
+		{
+			query: `DELETE FROM user_restrictions WHERE user_id = ? OR target_user_id = ?`,
+			args:  []interface{}{userID, userID},
+		},
+		{
+			query: `DELETE FROM notifications WHERE user_id = ? OR actor_id = ? OR post_id IN (SELECT id FROM beer_posts WHERE user_id = ?)`,
+			args:  []interface{}{userID, userID, userID},
+		},
+		{
+			query: `DELETE FROM devices WHERE user_id = ?`,
+			args:  []interface{}{userID},
+		},
+		{
+			query: `DELETE FROM notification_settings WHERE user_id = ?`,
+			args:  []interface{}{userID},
+		},
+		{
+			query: `DELETE FROM reactions WHERE user_id = ? OR post_id IN (SELECT id FROM beer_posts WHERE user_id = ?)`,
+			args:  []interface{}{userID, userID},
+		},
+		{
+			query: `DELETE FROM realmojis WHERE user_id = ? OR post_id IN (SELECT id FROM beer_posts WHERE user_id = ?)`,
+			args:  []interface{}{userID, userID},
+		},
+		{
+			query: `DELETE FROM user_achievements WHERE user_id = ?`,
+			args:  []interface{}{userID},
+		},
+		{
+			// The reversed votes no longer count towards anyone's history
+			query: `DELETE FROM taste_score_events WHERE user_id = ? OR voter_id = ?`,
+			args:  []interface{}{userID, userID},
+		},
+		{
+			query: `DELETE FROM votes WHERE user_id = ? OR post_id IN (SELECT id FROM beer_posts WHERE user_id = ?)`,
+			args:  []interface{}{userID, userID},
+		},
+		{
+			query: `DELETE FROM comments WHERE user_id = ? OR post_id IN (SELECT id FROM beer_posts WHERE user_id = ?)`,
+			args:  []interface{}{userID, userID},
+		},
+		{
+			query: `DELETE FROM beer_posts WHERE user_id = ?`,
+			args:  []interface{}{userID},
+		},
+		{
+			query: `DELETE FROM users WHERE id = ?`,
+			args:  []interface{}{userID},
+		},
+	}
+
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt.query, stmt.args...); err != nil {
+			return fmt.Errorf("failed to delete user data: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit user deletion: %w", err)
+	}
+	return nil
+}
+
+// friendOfViewerFilter matches users (u) with an accepted friendship with the
+// viewer bound to both placeholders.
+const friendOfViewerFilter = `EXISTS (
+	SELECT 1 FROM friendships f
+	WHERE f.status = 'ACCEPTED'
+	  AND ((f.user_id = ? AND f.friend_id = u.id) OR (f.friend_id = ? AND f.user_id = u.id))
+)`
+
+// GetTopScores ranks users by taste score. With a zero since it uses the
+// running taste_score; otherwise the score is the sum of the taste score
+// changes since then, so flipped and retracted votes count as they did. The global board leaves out private accounts the
+// viewer isn't friends with and users the viewer blocked or muted; the friends
+// board covers the viewer and their friends.
+func (r *userRepository) GetTopScores(viewerID string, friendsOnly bool, since time.Time, limit int) ([]models.UserScore, error) {
+	scoreJoin := ``
+	scoreColumn := `u.taste_score`
+	var args []interface{}
+	if !since.IsZero() {
+		scoreJoin = `JOIN (
+			SELECT e.user_id AS id, SUM(e.delta) AS score
+			FROM taste_score_events e
+			WHERE e.created_at >= ?
+			GROUP BY e.user_id
+		) s ON s.id = u.id`
+		scoreColumn = `s.score`
+		// Taste score events are stored in UTC
+		args = append(args, since.UTC())
+	}
+
+	var where string
+	if friendsOnly {
+		where = `(u.id = ? OR ` + friendOfViewerFilter + `)`
+		args = append(args, viewerID, viewerID, viewerID)
+	} else {
+		where = `(u.is_private = 0 OR u.id = ? OR ` + friendOfViewerFilter + `) AND ` + fmt.Sprintf(hiddenAuthorFilter, "u.id")
+		args = append(args, viewerID, viewerID, viewerID, viewerID)
+	}
+
+	query := `
+		SELECT u.id, u.username, u.profile_image_data, ` + scoreColumn + ` AS score
+		FROM users u
+		` + scoreJoin + `
+		WHERE ` + where + `
+		ORDER BY score DESC, u.username ASC
+		LIMIT ?
+	`
+	rows, err := r.db.Query(query, append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top scores: %w", err)
+	}
+	defer rows.Close()
+
+	scores := []models.UserScore{}
+	for rows.Next() {
+		var score models.UserScore
+		if err := rows.Scan(&score.UserID, &score.Username, &score.UserProfileImageData, &score.Score); err != nil {
+			return nil, fmt.Errorf("failed to scan score: %w", err)
+		}
+		scores = append(scores, score)
+	}
+
+	return scores, nil
 }
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/batku/beerreal/internal/models"
+	"github.com/google/uuid"
+)
+
+type VenueRepository interface {
+	GetVenueByID(venueID string) (*models.Venue, error)
+	UpsertVenue(venue *models.Venue) error
+}
+
+const venueSelectColumns = `id, place_id, name, address, latitude, longitude, created_at, updated_at`
+
+func scanVenue(row rowScanner, venue *models.Venue) error {
+	return row.Scan(
+		&venue.ID, &venue.PlaceID, &venue.Name, &venue.Address,
+		&venue.Latitude, &venue.Longitude, &venue.CreatedAt, &venue.UpdatedAt,
+	)
+}
+
+type venueRepository struct {
+	db *sql.DB
+}
+
+func NewVenueRepository(db *sql.DB) VenueRepository {
+	return &venueRepository{db: db}
+}
+
+func (r *venueRepository) GetVenueByID(venueID string) (*models.Venue, error) {
+	query := `SELECT ` + venueSelectColumns + ` FROM venues WHERE id = ?`
+
+	venue := &models.Venue{}
+	if err := scanVenue(r.db.QueryRow(query, venueID), venue); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get venue: %w", err)
+	}
+
+	return venue, nil
+}
+
+// UpsertVenue registers a venue by its place ID. Venues are shared by every
+// user posting there, so a known place ID keeps its stored details rather than
+// taking whatever a client sent. The venue is updated in place with the stored
+// row, so callers get the existing ID on conflict.
+func (r *venueRepository) UpsertVenue(venue *models.Venue) error {
+	now := time.Now()
+	_, err := r.db.Exec(`
+		INSERT INTO venues (id, place_id, name, address, latitude, longitude, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(place_id) DO NOTHING
+	`, uuid.New().String(), venue.PlaceID, venue.Name, venue.Address, venue.Latitude, venue.Longitude, now, now)
+	if err != nil {
+		return fmt.Errorf("failed to upsert venue: %w", err)
+	}
+
+	query := `SELECT ` + venueSelectColumns + ` FROM venues WHERE place_id = ?`
+	if err := scanVenue(r.db.QueryRow(query, venue.PlaceID), venue); err != nil {
+		return fmt.Errorf("failed to get venue: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"log"
+
+	"github.com/batku/beerreal/internal/events"
+	"github.com/batku/beerreal/internal/models"
+	"github.com/batku/beerreal/internal/repository"
+)
+
+// achievementEvent is the kind of activity that can unlock achievements.
+type achievementEvent string
+
+const (
+	achievementEventPost    achievementEvent = "post"
+	achievementEventVote    achievementEvent = "vote"
+	achievementEventComment achievementEvent = "comment"
+)
+
+// achievementRule unlocks its achievement once metric reaches the threshold.
+// It is only checked after one of its events.
+type achievementRule struct {
+	models.Achievement
+	events []achievementEvent
+	metric func(stats *models.AchievementStats) int
+}
+
+func (r achievementRule) triggeredBy(event achievementEvent) bool {
+	for _, e := range r.events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// achievementRules are all achievements, in the order they are listed to
+// users. IDs are stored, so never change or reuse one.
+var achievementRules = []achievementRule{
+	{
+		Achievement: models.Achievement{ID: "first_post", Name: "First Round", Description: "Share your first beer", Threshold: 1},
+		events:      []achievementEvent{achievementEventPost},
+		metric:      func(s *models.AchievementStats) int { return s.PostCount },
+	},
+	{
+		Achievement: models.Achievement{ID: "posts_50", Name: "Regular", Description: "Share 50 beers", Threshold: 50},
+		events:      []achievementEvent{achievementEventPost},
+		metric:      func(s *models.AchievementStats) int { return s.PostCount },
+	},
+	{
+		Achievement: models.Achievement{ID: "streak_7", Name: "Week Bender", Description: "Post on 7 days in a row", Threshold: 7},
+		events:      []achievementEvent{achievementEventPost},
+		metric:      func(s *models.AchievementStats) int { return s.LongestStreakDays },
+	},
+	{
+		Achievement: models.Achievement{ID: "breweries_10", Name: "Brewery Hopper", Description: "Try beers from 10 different breweries", Threshold: 10},
+		events:      []achievementEvent{achievementEventPost},
+		metric:      func(s *models.AchievementStats) int { return s.DistinctBreweries },
+	},
+	{
+		Achievement: models.Achievement{ID: "countries_5", Name: "Globetrotter", Description: "Try catalog beers from 5 different countries", Threshold: 5},
+		events:      []achievementEvent{achievementEventPost},
+		metric:      func(s *models.AchievementStats) int { return s.DistinctCountries },
+	},
+	{
+		Achievement: models.Achievement{ID: "venues_5", Name: "Bar Crawler", Description: "Post from 5 different venues", Threshold: 5},
+		events:      []achievementEvent{achievementEventPost},
+		metric:      func(s *models.AchievementStats) int { return s.DistinctVenues },
+	},
+	{
+		Achievement: models.Achievement{ID: "first_comment", Name: "Conversation Starter", Description: "Comment on a post", Threshold: 1},
+		events:      []achievementEvent{achievementEventComment},
+		metric:      func(s *models.AchievementStats) int { return s.CommentCount },
+	},
+	{
+		Achievement: models.Achievement{ID: "votes_100", Name: "Critic", Description: "Vote on 100 posts", Threshold: 100},
+		events:      []achievementEvent{achievementEventVote},
+		metric:      func(s *models.AchievementStats) int { return s.VotesCast },
+	},
+	{
+		Achievement: models.Achievement{ID: "upvotes_100", Name: "Crowd Pleaser", Description: "Receive 100 upvotes", Threshold: 100},
+		events:      []achievementEvent{achievementEventVote},
+		metric:      func(s *models.AchievementStats) int { return s.UpvotesReceived },
+	},
+}
+
+func findAchievementRule(id string) (achievementRule, bool) {
+	for _, rule := range achievementRules {
+		if rule.ID == id {
+			return rule, true
+		}
+	}
+	return achievementRule{}, false
+}
+
+type AchievementService struct {
+	repo repository.AchievementRepository
+}
+
+func NewAchievementService(repo repository.AchievementRepository) *AchievementService {
+	return &AchievementService{repo: repo}
+}
+
+// Subscribe evaluates achievements after posts, votes and comments. Delivery
+// is synchronous so that unlocks are visible as soon as the request returns.
+func (s *AchievementService) Subscribe(bus *events.Bus) {
+	bus.Subscribe("AchievementService", events.Sync, func(event events.Event) {
+		switch e := event.(type) {
+		case events.PostCreated:
+			s.evaluate(e.Post.UserID, achievementEventPost)
+		case events.VoteCast:
+			s.evaluate(e.VoterID, achievementEventVote)
+			s.evaluate(e.AuthorID, achievementEventVote)
+		case events.CommentAdded:
+			s.evaluate(e.Comment.UserID, achievementEventComment)
+		}
+	})
+}
+
+// evaluate runs Evaluate for an event handler, which can only log failures.
+func (s *AchievementService) evaluate(userID string, event achievementEvent) {
+	if _, err := s.Evaluate(userID, event); err != nil {
+		log.Printf("[AchievementService] ERROR: Failed to evaluate achievements for user %s: %v", userID, err)
+	}
+}
+
+// Evaluate checks the rules triggered by the event against the user's current
+// stats and returns the achievements that were newly unlocked.
+func (s *AchievementService) Evaluate(userID string, event achievementEvent) ([]models.UserAchievement, error) {
+	unlocks, err := s.repo.GetUnlocks(userID)
+	if err != nil {
+		return nil, err
+	}
+	unlocked := make(map[string]bool, len(unlocks))
+	for _, unlock := range unlocks {
+		unlocked[unlock.AchievementID] = true
+	}
+
+	var candidates []achievementRule
+	for _, rule := range achievementRules {
+		if !unlocked[rule.ID] && rule.triggeredBy(event) {
+			candidates = append(candidates, rule)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	stats, err := s.repo.GetAchievementStats(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var met []string
+	for _, rule := range candidates {
+		if rule.metric(stats) >= rule.Threshold {
+			met = append(met, rule.ID)
+		}
+	}
+	if len(met) == 0 {
+		return nil, nil
+	}
+
+	added, err := s.repo.AddUnlocks(userID, met)
+	if err != nil {
+		return nil, err
+	}
+	achievements := toUserAchievements(added)
+	for _, achievement := range achievements {
+		log.Printf("[AchievementService] User %s unlocked %s", userID, achievement.ID)
+	}
+	return achievements, nil
+}
+
+// GetUnlocked returns the achievements the user has unlocked, oldest first.
+func (s *AchievementService) GetUnlocked(userID string) ([]models.UserAchievement, error) {
+	unlocks, err := s.repo.GetUnlocks(userID)
+	if err != nil {
+		return nil, err
+	}
+	return toUserAchievements(unlocks), nil
+}
+
+// GetAchievements lists every achievement with the user's progress towards
+// it, capped at the threshold.
+func (s *AchievementService) GetAchievements(userID string) (*models.GetAchievementsResponse, error) {
+	unlocks, err := s.repo.GetUnlocks(userID)
+	if err != nil {
+		return nil, err
+	}
+	unlockedAt := make(map[string]models.AchievementUnlock, len(unlocks))
+	for _, unlock := range unlocks {
+		unlockedAt[unlock.AchievementID] = unlock
+	}
+
+	stats, err := s.repo.GetAchievementStats(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	achievements := make([]models.AchievementProgress, 0, len(achievementRules))
+	for _, rule := range achievementRules {
+		progress := models.AchievementProgress{Achievement: rule.Achievement}
+		if unlock, ok := unlockedAt[rule.ID]; ok {
+			progress.Unlocked = true
+			progress.UnlockedAt = &unlock.UnlockedAt
+			progress.Progress = rule.Threshold
+		} else {
+			progress.Progress = min(rule.metric(stats), rule.Threshold)
+		}
+		achievements = append(achievements, progress)
+	}
+
+	return &models.GetAchievementsResponse{Achievements: achievements}, nil
+}
+
+// toUserAchievements resolves stored unlocks against the rules, skipping any
+// achievement that no longer exists.
+func toUserAchievements(unlocks []models.AchievementUnlock) []models.UserAchievement {
+	achievements := make([]models.UserAchievement, 0, len(unlocks))
+	for _, unlock := range unlocks {
+		rule, ok := findAchievementRule(unlock.AchievementID)
+		if !ok {
+			continue
+		}
+		achievements = append(achievements, models.UserAchievement{Achievement: rule.Achievement, UnlockedAt: unlock.UnlockedAt})
+	}
+	return achievements
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/batku/beerreal/internal/models"
+	"github.com/batku/beerreal/internal/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultBeerSearchLimit = 10
+	maxBeerSearchLimit     = 50
+	beerTopLocations       = 5
+)
+
+var ErrBeerNotFound = errors.New("beer not found")
+
+type CatalogService struct {
+	repo     repository.CatalogRepository
+	postRepo repository.PostRepository
+}
+
+func NewCatalogService(repo repository.CatalogRepository, postRepo repository.PostRepository) *CatalogService {
+	return &CatalogService{repo: repo, postRepo: postRepo}
+}
+
+// GetBeer returns a catalog beer with aggregate stats and a page of the
+// posts about it that the viewer can see.
+func (s *CatalogService) GetBeer(beerID, viewerID string, page, pageSize int) (*models.GetBeerResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	beer, err := s.repo.GetBeerByID(beerID)
+	if err != nil {
+		return nil, err
+	}
+	if beer == nil {
+		return nil, ErrBeerNotFound
+	}
+
+	stats, err := s.postRepo.GetBeerStats(beerID, viewerID, beerTopLocations)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := (page - 1) * pageSize
+	posts, totalCount, err := s.postRepo.GetBeerPosts(beerID, viewerID, pageSize, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get beer posts: %w", err)
+	}
+
+	return &models.GetBeerResponse{
+		Beer:       *beer,
+		Stats:      *stats,
+		Posts:      posts,
+		TotalCount: totalCount,
+		Page:       page,
+		PageSize:   pageSize,
+	}, nil
+}
+
+// SearchBeers returns catalog beers whose name or aliases start with the
+// query, for autocomplete.
+func (s *CatalogService) SearchBeers(query string, limit int) (*models.SearchBeersResponse, error) {
+	if limit < 1 || limit > maxBeerSearchLimit {
+		limit = defaultBeerSearchLimit
+	}
+
+	normalized := normalizeName(query)
+	if normalized == "" {
+		return &models.SearchBeersResponse{Beers: []models.Beer{}}, nil
+	}
+
+	beers, err := s.repo.SearchBeers(normalized, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search beers: %w", err)
+	}
+	return &models.SearchBeersResponse{Beers: beers}, nil
+}
+
+// Import loads catalog entries, upserting breweries and beers. Entries without
+// an explicit ID get one derived from their normalized brewery and beer names,
+// so importing the same file twice updates rather than duplicates.
+func (s *CatalogService) Import(entries []models.CatalogEntry) (breweryCount, beerCount int, err error) {
+	breweries := []models.Brewery{}
+	breweryIDs := map[string]string{}
+	beers := make([]models.Beer, 0, len(entries))
+
+	for i, entry := range entries {
+		name := strings.TrimSpace(entry.Name)
+		if normalizeName(name) == "" {
+			return 0, 0, fmt.Errorf("entry %d: name is required", i+1)
+		}
+
+		beer := models.Beer{
+			Name:  name,
+			Style: optionalString(entry.Style),
+			ABV:   entry.ABV,
+		}
+
+		breweryName := strings.TrimSpace(entry.Brewery)
+		breweryKey := normalizeName(breweryName)
+		if breweryKey != "" {
+			breweryID, ok := breweryIDs[breweryKey]
+			if !ok {
+				breweryID = catalogID("brewery", breweryKey)
+				breweryIDs[breweryKey] = breweryID
+				breweries = append(breweries, models.Brewery{
+					ID:      breweryID,
+					Name:    breweryName,
+					Country: optionalString(entry.BreweryCountry),
+				})
+			}
+			beer.BreweryID = &breweryID
+		}
+
+		beer.ID = strings.TrimSpace(entry.ID)
+		if beer.ID == "" {
+			beer.ID = catalogID("beer", breweryKey+"/"+normalizeName(name))
+		}
+		beer.Aliases = beerAliases(name, breweryName, entry.Aliases)
+
+		beers = append(beers, beer)
+	}
+
+	if err := s.repo.ImportCatalog(breweries, beers); err != nil {
+		return 0, 0, err
+	}
+	return len(breweries), len(beers), nil
+}
+
+// beerAliases returns the distinct normalized names a beer can be matched by:
+// its own name, its name prefixed with the brewery, and any extra aliases.
+func beerAliases(name, brewery string, extra []string) []string {
+	candidates := []string{name}
+	if !strings.HasPrefix(normalizeName(name), normalizeName(brewery)) {
+		candidates = append(candidates, brewery+" "+name)
+	}
+	candidates = append(candidates, extra...)
+
+	seen := map[string]bool{}
+	aliases := []string{}
+	for _, candidate := range candidates {
+		alias := normalizeName(candidate)
+		if alias == "" || seen[alias] {
+			continue
+		}
+		seen[alias] = true
+		aliases = append(aliases, alias)
+	}
+	return aliases
+}
+
+// catalogID derives a stable ID from a normalized catalog key.
+func catalogID(kind, key string) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte("beerreal:"+kind+":"+key)).String()
+}
+
+// normalizeName lowercases the name, drops punctuation and collapses
+// whitespace so that "Guinness  Draught!" and "guinness draught" match.
+func normalizeName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+func optionalString(value string) *string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	return &value
+}
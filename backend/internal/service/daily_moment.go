@@ -0,0 +1,100 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"time"
+
+	"github.com/batku/beerreal/internal/models"
+	"github.com/batku/beerreal/internal/streak"
+)
+
+const dailyMomentCheckInterval = time.Minute
+
+// dailyMomentTime returns the moment of the given local date in loc. Every
+// date gets its own pseudo-random minute between startHour and endHour, the
+// same wall-clock time for every user.
+func dailyMomentTime(date time.Time, loc *time.Location, startHour, endHour int) time.Time {
+	day := date.In(loc)
+	hash := fnv.New32a()
+	hash.Write([]byte(day.Format(streak.DateLayout)))
+	minute := int(hash.Sum32() % uint32((endHour-startHour)*60))
+	return time.Date(day.Year(), day.Month(), day.Day(), startHour, minute, 0, 0, loc)
+}
+
+// SendDailyMoments pushes the daily moment to every user whose moment has
+// passed today in their time zone and who hasn't had it yet, and returns how
+// many were sent. Users who turned the daily moment off are skipped, and
+// during quiet hours it waits until they end, unless the day is over by then.
+// A failure for one user is logged and doesn't stop the others; they are
+// retried on the next run.
+func (s *NotificationService) SendDailyMoments(now time.Time, startHour, endHour int) (int, error) {
+	if s.dispatcher == nil {
+		return 0, nil
+	}
+
+	recipients, err := s.repo.GetDailyMomentRecipients()
+	if err != nil {
+		return 0, err
+	}
+
+	sent, failed := 0, 0
+	for _, recipient := range recipients {
+		loc := streak.Location(recipient.TimeZone)
+		today := streak.LocalDate(now, loc, 0)
+		if recipient.LastMomentDate != nil && *recipient.LastMomentDate == today {
+			continue
+		}
+		if now.Before(dailyMomentTime(now, loc, startHour, endHour)) {
+			continue
+		}
+
+		settings, err := s.repo.GetSettings(recipient.UserID)
+		if err != nil {
+			log.Printf("[NotificationService] ERROR: Failed to get settings for daily moment of user %s: %v", recipient.UserID, err)
+			failed++
+			continue
+		}
+		if settings == nil || !notificationEnabled(settings, models.NotificationTypeDailyMoment) || inQuietHours(settings, now) {
+			continue
+		}
+
+		// Mark first so that a crash can't send the same moment twice
+		marked, err := s.repo.MarkDailyMomentSent(recipient.UserID, today)
+		if err != nil {
+			log.Printf("[NotificationService] ERROR: Failed to mark daily moment of user %s: %v", recipient.UserID, err)
+			failed++
+			continue
+		}
+		if !marked {
+			continue
+		}
+		s.dispatcher.Dispatch(models.Notification{UserID: recipient.UserID, Type: models.NotificationTypeDailyMoment})
+		sent++
+	}
+	if failed > 0 {
+		return sent, fmt.Errorf("daily moment failed for %d users", failed)
+	}
+	return sent, nil
+}
+
+// RunDailyMomentJob sends the daily moment as it comes due for each user. It
+// blocks forever, so run it in its own goroutine.
+func (s *NotificationService) RunDailyMomentJob(startHour, endHour int) error {
+	if startHour < 0 || endHour > 24 || startHour >= endHour {
+		return errors.New("daily moment hours must satisfy 0 <= start < end <= 24")
+	}
+
+	for {
+		sent, err := s.SendDailyMoments(time.Now(), startHour, endHour)
+		if err != nil {
+			log.Printf("[NotificationService] ERROR: Daily moment failed: %v", err)
+		}
+		if sent > 0 {
+			log.Printf("[NotificationService] Sent the daily moment to %d users", sent)
+		}
+		time.Sleep(dailyMomentCheckInterval)
+	}
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/batku/beerreal/internal/models"
+	"github.com/batku/beerreal/internal/push"
+	"github.com/batku/beerreal/internal/repository"
+)
+
+func TestSendDailyMomentsOncePerDay(t *testing.T) {
+	db := newTestDB(t)
+	addUserWithDevices(t, db, "alice", "token-1")
+	addUserWithDevices(t, db, "bob")
+
+	sender := push.NewFakeSender()
+	devices := repository.NewDeviceRepository(db.DB)
+	worker := NewPushWorker(devices, sender, 3, time.Millisecond)
+	worker.Start(1)
+
+	userRepo := repository.NewUserRepository(db.DB)
+	postRepo := repository.NewPostRepository(db.DB, 0)
+	relationRepo := repository.NewRelationshipRepository(db.DB)
+	notifications := NewNotificationService(repository.NewNotificationRepository(db.DB), devices, userRepo, postRepo, relationRepo, worker)
+
+	// Every moment between 10:00 and 22:00 has passed by 23:00
+	today := time.Now().UTC()
+	now := time.Date(today.Year(), today.Month(), today.Day(), 23, 0, 0, 0, time.UTC)
+
+	sent, err := notifications.SendDailyMoments(now, 10, 22)
+	if err != nil {
+		t.Fatalf("SendDailyMoments failed: %v", err)
+	}
+	if sent != 1 {
+		t.Errorf("sent = %d, want 1 (bob has no device)", sent)
+	}
+	sent, err = notifications.SendDailyMoments(now.Add(30*time.Minute), 10, 22)
+	if err != nil {
+		t.Fatalf("SendDailyMoments failed: %v", err)
+	}
+	if sent != 0 {
+		t.Errorf("second run sent = %d, want 0", sent)
+	}
+
+	waitFor(t, "the daily moment push", func() bool { return len(sender.Sent()) == 1 })
+	message := sender.Sent()[0]
+	if message.Data["type"] != string(models.NotificationTypeDailyMoment) {
+		t.Errorf("type = %q, want %s", message.Data["type"], models.NotificationTypeDailyMoment)
+	}
+	if _, ok := message.Data["notificationId"]; ok {
+		t.Error("daily moment push has a notificationId")
+	}
+}
+
+func TestSendDailyMomentsContinuesAfterFailure(t *testing.T) {
+	db := newTestDB(t)
+	addUserWithDevices(t, db, "alice", "token-1")
+	addUserWithDevices(t, db, "bob", "token-2")
+	// Settings that can't be read make alice's moment fail
+	if _, err := db.DB.Exec(`INSERT INTO notification_settings (user_id, votes) VALUES ('alice', 'garbage')`); err != nil {
+		t.Fatalf("failed to insert settings: %v", err)
+	}
+
+	sender := push.NewFakeSender()
+	devices := repository.NewDeviceRepository(db.DB)
+	worker := NewPushWorker(devices, sender, 3, time.Millisecond)
+	worker.Start(1)
+
+	userRepo := repository.NewUserRepository(db.DB)
+	postRepo := repository.NewPostRepository(db.DB, 0)
+	relationRepo := repository.NewRelationshipRepository(db.DB)
+	notifications := NewNotificationService(repository.NewNotificationRepository(db.DB), devices, userRepo, postRepo, relationRepo, worker)
+
+	today := time.Now().UTC()
+	now := time.Date(today.Year(), today.Month(), today.Day(), 23, 0, 0, 0, time.UTC)
+
+	sent, err := notifications.SendDailyMoments(now, 10, 22)
+	if err == nil {
+		t.Error("expected an error for alice")
+	}
+	if sent != 1 {
+		t.Errorf("sent = %d, want 1", sent)
+	}
+	waitFor(t, "bob's daily moment", func() bool { return len(sender.Sent()) == 1 })
+	if token := sender.Sent()[0].Token; token != "token-2" {
+		t.Errorf("sent to %q, want token-2", token)
+	}
+}
@@ -0,0 +1,25 @@
+package service
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// validateImageData checks that an uploaded image is a base64 data URI with
+// an image MIME type, that it decodes, and that it is at most maxLength
+// bytes long. Errors name the field and describe the image as label, e.g.
+// "profile image".
+func validateImageData(field, label, dataURI string, maxLength int) error {
+	header, payload, found := strings.Cut(dataURI, ";base64,")
+	if !found || !strings.HasPrefix(header, "data:image/") {
+		return &ValidationError{Field: field, Message: fmt.Sprintf("%s must be a base64 data URI", label)}
+	}
+	if len(dataURI) > maxLength {
+		return &ValidationError{Field: field, Message: fmt.Sprintf("%s is too large", label)}
+	}
+	if _, err := base64.StdEncoding.DecodeString(payload); err != nil {
+		return &ValidationError{Field: field, Message: fmt.Sprintf("%s is not valid base64", label)}
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package service
+
+import (
+	"time"
+
+	"github.com/batku/beerreal/internal/models"
+)
+
+// leaderboardSince returns the start of a leaderboard period ending at now;
+// the zero time covers all time.
+func leaderboardSince(period models.LeaderboardPeriod, now time.Time) (time.Time, bool) {
+	switch period {
+	case models.LeaderboardPeriodAll:
+		return time.Time{}, true
+	case models.LeaderboardPeriodWeek:
+		return now.AddDate(0, 0, -7), true
+	case models.LeaderboardPeriodMonth:
+		return now.AddDate(0, -1, 0), true
+	}
+	return time.Time{}, false
+}
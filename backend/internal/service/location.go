@@ -0,0 +1,52 @@
+package service
+
+import (
+	"math"
+	"math/rand"
+)
+
+const metersPerDegreeLatitude = 111320.0
+
+// LocationFuzzer coarsens post coordinates before they are stored so that
+// posts do not reveal exactly where someone lives or drinks.
+type LocationFuzzer struct {
+	// RoundingDecimals is the number of decimal places to keep; negative
+	// disables rounding. Three decimals is roughly 110 m.
+	RoundingDecimals int
+	// JitterMeters randomly offsets the point by up to this distance before
+	// rounding; zero disables jitter.
+	JitterMeters float64
+}
+
+// Apply returns the fuzzed coordinates and the radius in meters by which
+// they may differ from the originals.
+func (f LocationFuzzer) Apply(lat, lng float64) (float64, float64, float64) {
+	var radius float64
+
+	if f.JitterMeters > 0 {
+		distance := f.JitterMeters * math.Sqrt(rand.Float64())
+		bearing := 2 * math.Pi * rand.Float64()
+		lat += distance * math.Cos(bearing) / metersPerDegreeLatitude
+		if cosLat := math.Cos(lat * math.Pi / 180); cosLat > 1e-6 {
+			lng += distance * math.Sin(bearing) / (metersPerDegreeLatitude * cosLat)
+		}
+		radius += f.JitterMeters
+	}
+
+	if f.RoundingDecimals >= 0 {
+		scale := math.Pow(10, float64(f.RoundingDecimals))
+		lat = math.Round(lat*scale) / scale
+		lng = math.Round(lng*scale) / scale
+		// Half a step in each axis; the diagonal bounds the error
+		radius += math.Sqrt2 * 0.5 / scale * metersPerDegreeLatitude
+	}
+
+	lat = math.Max(-90, math.Min(90, lat))
+	if lng > 180 {
+		lng -= 360
+	} else if lng < -180 {
+		lng += 360
+	}
+
+	return lat, lng, radius
+}
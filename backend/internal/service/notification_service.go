@@ -0,0 +1,346 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/batku/beerreal/internal/events"
+	"github.com/batku/beerreal/internal/models"
+	"github.com/batku/beerreal/internal/repository"
+)
+
+const (
+	defaultNotificationLimit   = 20
+	maxNotificationLimit       = 100
+	maxMarkReadIDs             = 100
+	notificationPreviewLength  = 100
+	maxMentionsPerNotification = 10
+	maxDeviceTokenLength       = 4096
+)
+
+// mentionPattern matches @username where the @ is not part of a word, e.g.
+// an email address.
+var mentionPattern = regexp.MustCompile(`(?:^|[^A-Za-z0-9_.@-])@([A-Za-z0-9_.-]{3,30})`)
+
+// NotificationDispatcher delivers stored notifications outside the app, e.g.
+// as push messages.
+type NotificationDispatcher interface {
+	Dispatch(notification models.Notification)
+}
+
+type NotificationService struct {
+	repo         repository.NotificationRepository
+	deviceRepo   repository.DeviceRepository
+	userRepo     repository.UserRepository
+	postRepo     repository.PostRepository
+	relationRepo repository.RelationshipRepository
+	// dispatcher is nil when push delivery is disabled.
+	dispatcher NotificationDispatcher
+}
+
+func NewNotificationService(repo repository.NotificationRepository, deviceRepo repository.DeviceRepository, userRepo repository.UserRepository, postRepo repository.PostRepository, relationRepo repository.RelationshipRepository, dispatcher NotificationDispatcher) *NotificationService {
+	return &NotificationService{
+		repo:         repo,
+		deviceRepo:   deviceRepo,
+		userRepo:     userRepo,
+		postRepo:     postRepo,
+		relationRepo: relationRepo,
+		dispatcher:   dispatcher,
+	}
+}
+
+// Subscribe sends notifications about posts, votes, comments and friend
+// requests. Delivery is asynchronous since nothing in the request depends on
+// it.
+func (s *NotificationService) Subscribe(bus *events.Bus) {
+	bus.Subscribe("NotificationService", events.Async, func(event events.Event) {
+		switch e := event.(type) {
+		case events.PostCreated:
+			s.notifyMentions(e.Post.ID, e.Post.UserID, e.Post.Caption, nil, "")
+		case events.VoteCast:
+			// Taking a vote back is not worth a notification
+			if !e.Removed {
+				s.notifyVote(e)
+			}
+		case events.CommentAdded:
+			s.notifyComment(e.PostAuthorID, &e.Comment)
+		case events.FriendRequested:
+			s.notifyFriendRequest(e.FromUserID, e.ToUserID)
+		case events.FriendAccepted:
+			s.notifyFriendAccepted(e.AccepterID, e.RequesterID)
+		}
+	})
+}
+
+// notifyVote tells the post's author about a vote on it.
+func (s *NotificationService) notifyVote(vote events.VoteCast) {
+	s.notify(&models.Notification{
+		UserID:   vote.AuthorID,
+		Type:     models.NotificationTypeVote,
+		ActorID:  vote.VoterID,
+		PostID:   &vote.PostID,
+		VoteType: &vote.VoteType,
+	})
+}
+
+// notifyComment tells the post's author about a comment on it and anyone
+// mentioned in the comment.
+func (s *NotificationService) notifyComment(postAuthorID string, comment *models.Comment) {
+	s.notify(&models.Notification{
+		UserID:    postAuthorID,
+		Type:      models.NotificationTypeComment,
+		ActorID:   comment.UserID,
+		PostID:    &comment.PostID,
+		CommentID: &comment.ID,
+		Text:      previewText(comment.Text),
+	})
+	s.notifyMentions(comment.PostID, comment.UserID, comment.Text, &comment.ID, postAuthorID)
+}
+
+// notifyFriendRequest tells the user about a friend request they received.
+func (s *NotificationService) notifyFriendRequest(fromUserID, toUserID string) {
+	s.notify(&models.Notification{
+		UserID:  toUserID,
+		Type:    models.NotificationTypeFriendRequest,
+		ActorID: fromUserID,
+	})
+}
+
+// notifyFriendAccepted tells the user who sent a friend request that it was
+// accepted.
+func (s *NotificationService) notifyFriendAccepted(accepterID, requesterID string) {
+	s.notify(&models.Notification{
+		UserID:  requesterID,
+		Type:    models.NotificationTypeFriendAccepted,
+		ActorID: accepterID,
+	})
+}
+
+// notifyMentions notifies the users mentioned in text who can see the post,
+// except skipUserID, who is already notified about the same activity.
+func (s *NotificationService) notifyMentions(postID, actorID, text string, commentID *string, skipUserID string) {
+	seen := map[string]bool{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		if len(seen) >= maxMentionsPerNotification {
+			break
+		}
+		user, err := s.findMentionedUser(match[1])
+		if err != nil {
+			log.Printf("[NotificationService] ERROR: Failed to resolve mention @%s: %v", match[1], err)
+			continue
+		}
+		if user == nil || seen[user.ID] || user.ID == skipUserID {
+			continue
+		}
+		seen[user.ID] = true
+
+		if _, err := s.postRepo.GetPostByID(postID, user.ID); err != nil {
+			if !errors.Is(err, repository.ErrPostNotFound) {
+				log.Printf("[NotificationService] ERROR: Failed to check post visibility: %v", err)
+			}
+			continue
+		}
+
+		s.notify(&models.Notification{
+			UserID:    user.ID,
+			Type:      models.NotificationTypeMention,
+			ActorID:   actorID,
+			PostID:    &postID,
+			CommentID: commentID,
+			Text:      previewText(text),
+		})
+	}
+}
+
+// findMentionedUser resolves a mentioned username, retrying without trailing
+// punctuation such as the period ending a sentence.
+func (s *NotificationService) findMentionedUser(username string) (*models.User, error) {
+	user, err := s.userRepo.GetUserByUsername(username)
+	if err != nil || user != nil {
+		return user, err
+	}
+	if trimmed := strings.TrimRight(username, ".-"); trimmed != username && len(trimmed) >= 3 {
+		return s.userRepo.GetUserByUsername(trimmed)
+	}
+	return nil, nil
+}
+
+// notify stores the notification unless it is about the recipient's own
+// activity, the recipient has blocked or muted the actor, or turned off this
+// type of notification. No push is sent during the recipient's quiet hours.
+// Repeated votes by the same user on a post update their unread vote
+// notification instead of adding another one and are not pushed again.
+// Failures are logged and never fail the action that triggered the
+// notification.
+func (s *NotificationService) notify(notification *models.Notification) {
+	if notification.UserID == notification.ActorID {
+		return
+	}
+
+	restriction, err := s.relationRepo.GetRestriction(notification.UserID, notification.ActorID)
+	if err != nil {
+		log.Printf("[NotificationService] ERROR: Failed to check restrictions: %v", err)
+		return
+	}
+	if restriction != nil {
+		return
+	}
+
+	settings, err := s.repo.GetSettings(notification.UserID)
+	if err != nil {
+		log.Printf("[NotificationService] ERROR: Failed to get notification settings: %v", err)
+		return
+	}
+	if settings == nil || !notificationEnabled(settings, notification.Type) {
+		return
+	}
+
+	if notification.Type == models.NotificationTypeVote {
+		refreshed, err := s.repo.RefreshUnreadVoteNotification(notification)
+		if err != nil {
+			log.Printf("[NotificationService] ERROR: Failed to refresh vote notification for user %s: %v", notification.UserID, err)
+			return
+		}
+		if refreshed {
+			return
+		}
+	}
+
+	if err := s.repo.CreateNotification(notification); err != nil {
+		log.Printf("[NotificationService] ERROR: Failed to create %s notification for user %s: %v", notification.Type, notification.UserID, err)
+		return
+	}
+
+	if s.dispatcher != nil && !inQuietHours(settings, time.Now()) {
+		actor, err := s.userRepo.GetUserByID(notification.ActorID)
+		if err != nil {
+			log.Printf("[NotificationService] ERROR: Failed to get actor: %v", err)
+		} else if actor != nil {
+			notification.ActorUsername = actor.Username
+			notification.ActorProfileImageData = actor.ProfileImageData
+		}
+		s.dispatcher.Dispatch(*notification)
+	}
+}
+
+// RegisterDevice stores a push token for the user's device.
+func (s *NotificationService) RegisterDevice(userID string, req *models.RegisterDeviceRequest) (*models.Device, error) {
+	token := strings.TrimSpace(req.Token)
+	if token == "" || len(token) > maxDeviceTokenLength {
+		return nil, &ValidationError{Field: "token", Message: "token must be 1-4096 characters"}
+	}
+	if !req.Platform.IsValid() {
+		return nil, &ValidationError{Field: "platform", Message: "platform must be ANDROID, IOS or WEB"}
+	}
+
+	device := &models.Device{Token: token, UserID: userID, Platform: req.Platform}
+	if err := s.deviceRepo.RegisterDevice(device); err != nil {
+		return nil, err
+	}
+	return device, nil
+}
+
+// UnregisterDevice stops push messages to the device, e.g. on sign-out.
+func (s *NotificationService) UnregisterDevice(userID, token string) error {
+	return s.deviceRepo.DeleteDevice(userID, strings.TrimSpace(token))
+}
+
+// GetNotifications returns a page of the user's inbox, newest first, with the
+// cursor for the next page.
+func (s *NotificationService) GetNotifications(userID, cursor string, limit int) (*models.GetNotificationsResponse, error) {
+	if limit < 1 || limit > maxNotificationLimit {
+		limit = defaultNotificationLimit
+	}
+
+	var before *repository.NotificationCursor
+	if cursor != "" {
+		decoded, err := decodeNotificationCursor(cursor)
+		if err != nil {
+			return nil, &ValidationError{Field: "cursor", Message: "invalid cursor"}
+		}
+		before = decoded
+	}
+
+	// Fetch one extra to know whether there is another page
+	notifications, err := s.repo.GetNotifications(userID, before, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	var nextCursor *string
+	if len(notifications) > limit {
+		notifications = notifications[:limit]
+		last := notifications[limit-1]
+		next := encodeNotificationCursor(repository.NotificationCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+		nextCursor = &next
+	}
+
+	unread, err := s.repo.CountUnread(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.GetNotificationsResponse{
+		Notifications: notifications,
+		UnreadCount:   unread,
+		NextCursor:    nextCursor,
+	}, nil
+}
+
+// MarkRead marks the listed notifications, or all of them if none are
+// listed, as read and returns the remaining unread count.
+func (s *NotificationService) MarkRead(userID string, req *models.MarkNotificationsReadRequest) (*models.MarkNotificationsReadResponse, error) {
+	if len(req.IDs) > maxMarkReadIDs {
+		return nil, &ValidationError{Field: "ids", Message: "at most 100 ids can be marked at once"}
+	}
+
+	var err error
+	if len(req.IDs) == 0 {
+		err = s.repo.MarkAllRead(userID)
+	} else {
+		err = s.repo.MarkRead(userID, req.IDs)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	unread, err := s.repo.CountUnread(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.MarkNotificationsReadResponse{UnreadCount: unread}, nil
+}
+
+func encodeNotificationCursor(cursor repository.NotificationCursor) string {
+	raw := cursor.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + cursor.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeNotificationCursor(cursor string) (*repository.NotificationCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+	createdAt, id, found := strings.Cut(string(raw), "|")
+	if !found || id == "" {
+		return nil, errors.New("malformed cursor")
+	}
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	return &repository.NotificationCursor{CreatedAt: t, ID: id}, nil
+}
+
+// previewText shortens text for display in a notification.
+func previewText(text string) *string {
+	runes := []rune(strings.TrimSpace(text))
+	if len(runes) > notificationPreviewLength {
+		runes = append(runes[:notificationPreviewLength-1], '…')
+	}
+	preview := string(runes)
+	return &preview
+}
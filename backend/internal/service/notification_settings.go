@@ -0,0 +1,134 @@
+package service
+
+import (
+	"time"
+
+	"github.com/batku/beerreal/internal/models"
+	"github.com/batku/beerreal/internal/streak"
+)
+
+const quietHoursLayout = "15:04"
+
+func (s *NotificationService) GetSettings(userID string) (*models.NotificationSettings, error) {
+	settings, err := s.repo.GetSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		return nil, ErrUserNotFound
+	}
+	return settings, nil
+}
+
+// UpdateSettings applies the set fields of the request to the user's
+// notification settings.
+func (s *NotificationService) UpdateSettings(userID string, req *models.UpdateNotificationSettingsRequest) (*models.NotificationSettings, error) {
+	settings, err := s.GetSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	toggles := []struct {
+		value  *bool
+		target *bool
+	}{
+		{req.Votes, &settings.Votes},
+		{req.Comments, &settings.Comments},
+		{req.Mentions, &settings.Mentions},
+		{req.FriendRequests, &settings.FriendRequests},
+		{req.FriendAccepted, &settings.FriendAccepted},
+		{req.DailyMoment, &settings.DailyMoment},
+	}
+	for _, toggle := range toggles {
+		if toggle.value != nil {
+			*toggle.target = *toggle.value
+		}
+	}
+
+	if req.QuietHoursStart != nil || req.QuietHoursEnd != nil {
+		if req.QuietHoursStart == nil || req.QuietHoursEnd == nil {
+			return nil, &ValidationError{Field: "quietHours", Message: "quietHoursStart and quietHoursEnd must be set together"}
+		}
+		start, err := parseQuietHour("quietHoursStart", *req.QuietHoursStart)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseQuietHour("quietHoursEnd", *req.QuietHoursEnd)
+		if err != nil {
+			return nil, err
+		}
+		if (start == nil) != (end == nil) {
+			return nil, &ValidationError{Field: "quietHours", Message: "quietHoursStart and quietHoursEnd must both be empty to turn quiet hours off"}
+		}
+		if start != nil && *start == *end {
+			return nil, &ValidationError{Field: "quietHours", Message: "quiet hours must not start and end at the same time"}
+		}
+		settings.QuietHoursStart = start
+		settings.QuietHoursEnd = end
+	}
+
+	if err := s.repo.SaveSettings(userID, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// parseQuietHour normalizes an "HH:MM" time; an empty value means no quiet
+// hours.
+func parseQuietHour(field, value string) (*string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(quietHoursLayout, value)
+	if err != nil {
+		return nil, &ValidationError{Field: field, Message: "must be a time in HH:MM format"}
+	}
+	normalized := t.Format(quietHoursLayout)
+	return &normalized, nil
+}
+
+// notificationEnabled reports whether the user wants notifications of the
+// given type at all.
+func notificationEnabled(settings *models.NotificationSettings, notificationType models.NotificationType) bool {
+	switch notificationType {
+	case models.NotificationTypeVote:
+		return settings.Votes
+	case models.NotificationTypeComment:
+		return settings.Comments
+	case models.NotificationTypeMention:
+		return settings.Mentions
+	case models.NotificationTypeFriendRequest:
+		return settings.FriendRequests
+	case models.NotificationTypeFriendAccepted:
+		return settings.FriendAccepted
+	case models.NotificationTypeDailyMoment:
+		return settings.DailyMoment
+	}
+	return true
+}
+
+// inQuietHours reports whether now falls within the user's quiet hours. The
+// start is inclusive and the end exclusive; a start after the end spans
+// midnight.
+func inQuietHours(settings *models.NotificationSettings, now time.Time) bool {
+	if settings.QuietHoursStart == nil || settings.QuietHoursEnd == nil {
+		return false
+	}
+	start, err := time.Parse(quietHoursLayout, *settings.QuietHoursStart)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse(quietHoursLayout, *settings.QuietHoursEnd)
+	if err != nil {
+		return false
+	}
+
+	local := now.In(streak.Location(settings.TimeZone))
+	minute := local.Hour()*60 + local.Minute()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+	if startMinute < endMinute {
+		return minute >= startMinute && minute < endMinute
+	}
+	return minute >= startMinute || minute < endMinute
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/batku/beerreal/internal/models"
+	"github.com/batku/beerreal/internal/repository"
+)
+
+const outboxBatchSize = 100
+
+// OutboxHandler applies one outbox message. Messages are delivered at least
+// once, so handlers must be idempotent, e.g. by keying on the message ID.
+type OutboxHandler func(message *models.OutboxMessage) error
+
+// OutboxDispatcher delivers outbox messages to their handlers. A failed
+// message is retried after retryDelay * 2^(attempts-1) and dead-lettered
+// after maxAttempts.
+type OutboxDispatcher struct {
+	repo        repository.OutboxRepository
+	handlers    map[string]OutboxHandler
+	maxAttempts int
+	retryDelay  time.Duration
+	wake        chan struct{}
+}
+
+func NewOutboxDispatcher(repo repository.OutboxRepository, maxAttempts int, retryDelay time.Duration) *OutboxDispatcher {
+	return &OutboxDispatcher{
+		repo:        repo,
+		handlers:    map[string]OutboxHandler{},
+		maxAttempts: maxAttempts,
+		retryDelay:  retryDelay,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// Handle registers the handler for a message type. It must be called before
+// Run.
+func (d *OutboxDispatcher) Handle(messageType string, handler OutboxHandler) {
+	d.handlers[messageType] = handler
+}
+
+// Wake makes Run deliver new messages right away instead of at the next poll.
+func (d *OutboxDispatcher) Wake() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run delivers due messages every pollInterval, or sooner when woken. It
+// blocks forever, so start it in its own goroutine.
+func (d *OutboxDispatcher) Run(pollInterval time.Duration) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.DispatchDue()
+		select {
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// DispatchDue delivers all messages that are currently due.
+func (d *OutboxDispatcher) DispatchDue() {
+	for {
+		messages, err := d.repo.GetDue(time.Now(), outboxBatchSize)
+		if err != nil {
+			log.Printf("[OutboxDispatcher] ERROR: Failed to get due messages: %v", err)
+			return
+		}
+		for i := range messages {
+			d.deliver(&messages[i])
+		}
+		if len(messages) < outboxBatchSize {
+			return
+		}
+	}
+}
+
+func (d *OutboxDispatcher) deliver(message *models.OutboxMessage) {
+	handler, ok := d.handlers[message.Type]
+	if !ok {
+		d.deadLetter(message, fmt.Errorf("no handler for message type %s", message.Type))
+		return
+	}
+
+	err := handler(message)
+	if err == nil {
+		if err := d.repo.MarkDelivered(message.ID); err != nil {
+			log.Printf("[OutboxDispatcher] ERROR: %v", err)
+		}
+		return
+	}
+
+	attempts := message.Attempts + 1
+	if attempts >= d.maxAttempts {
+		d.deadLetter(message, err)
+		return
+	}
+
+	delay := d.retryDelay << (attempts - 1)
+	log.Printf("[OutboxDispatcher] %s message %d failed (attempt %d), retrying in %s: %v", message.Type, message.ID, attempts, delay, err)
+	if err := d.repo.MarkFailed(message.ID, err.Error(), time.Now().Add(delay)); err != nil {
+		log.Printf("[OutboxDispatcher] ERROR: %v", err)
+	}
+}
+
+func (d *OutboxDispatcher) deadLetter(message *models.OutboxMessage, cause error) {
+	log.Printf("[OutboxDispatcher] ERROR: Dead-lettering %s message %d after %d attempts: %v", message.Type, message.ID, message.Attempts+1, cause)
+	if err := d.repo.MarkDeadLettered(message.ID, cause.Error()); err != nil {
+		log.Printf("[OutboxDispatcher] ERROR: %v", err)
+	}
+}
@@ -1,12 +1,18 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"math"
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/batku/beerreal/internal/events"
 	"github.com/batku/beerreal/internal/models"
 	"github.com/batku/beerreal/internal/repository"
+	"github.com/batku/beerreal/internal/streak"
 	"github.com/google/uuid"
 )
 
@@ -15,20 +21,48 @@ type PostService interface {
 	GetPostByID(postID string, userID string) (*models.BeerPost, error)
 	GetPosts(userID string, page, pageSize int) (*models.GetPostsResponse, error)
 	GetUserPosts(targetUserID string, currentUserID string, page, pageSize int) (*models.GetPostsResponse, error)
+	GetNearbyPosts(userID string, lat, lng, radiusKm float64, hours, limit int) (*models.GetNearbyPostsResponse, error)
 	EnsureUserExists(userID, email, username string) error
 	VotePost(userID string, req *models.VoteRequest) (*models.VoteResponse, error)
 	AddComment(userID string, req *models.AddCommentRequest) (*models.Comment, error)
 }
 
+const (
+	defaultNearbyRadiusKm = 5
+	maxNearbyRadiusKm     = 50
+	defaultNearbyLimit    = 100
+	maxNearbyLimit        = 500
+	maxNearbyHours        = 24 * 30
+)
+
+var (
+	// ErrBlocked is returned when the post's author has blocked the acting user.
+	ErrBlocked = errors.New("you have been blocked by the author of this post")
+	// ErrPostNotFound is also returned for posts the caller is not allowed to see.
+	ErrPostNotFound = repository.ErrPostNotFound
+)
+
 type postService struct {
-	repo     repository.PostRepository
-	userRepo repository.UserRepository
+	repo         repository.PostRepository
+	userRepo     repository.UserRepository
+	relationRepo repository.RelationshipRepository
+	catalogRepo  repository.CatalogRepository
+	venueRepo    repository.VenueRepository
+	bus          *events.Bus
+	outbox       *OutboxDispatcher
+	fuzzer       LocationFuzzer
 }
 
-func NewPostService(repo repository.PostRepository, userRepo repository.UserRepository) PostService {
+func NewPostService(repo repository.PostRepository, userRepo repository.UserRepository, relationRepo repository.RelationshipRepository, catalogRepo repository.CatalogRepository, venueRepo repository.VenueRepository, bus *events.Bus, outbox *OutboxDispatcher, fuzzer LocationFuzzer) PostService {
 	return &postService{
-		repo:     repo,
-		userRepo: userRepo,
+		repo:         repo,
+		userRepo:     userRepo,
+		relationRepo: relationRepo,
+		catalogRepo:  catalogRepo,
+		venueRepo:    venueRepo,
+		bus:          bus,
+		outbox:       outbox,
+		fuzzer:       fuzzer,
 	}
 }
 
@@ -46,14 +80,62 @@ func (s *postService) CreatePost(userID string, req *models.CreatePostRequest) (
 	}
 	log.Printf("[PostService] User found: %s", user.Username)
 
+	visibility := req.Visibility
+	if visibility == "" {
+		visibility = models.PostVisibilityPublic
+	}
+	if !visibility.IsValid() {
+		return nil, &ValidationError{Field: "visibility", Message: "visibility must be PUBLIC, FRIENDS or PRIVATE"}
+	}
+
+	beer, err := normalizeBeerDetails(req.BeerDetails)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.linkCatalogBeer(&beer); err != nil {
+		return nil, err
+	}
+
+	geo, err := s.normalizeGeoLocation(req.GeoLocation)
+	if err != nil {
+		return nil, err
+	}
+
+	location := req.Location
+	var venueID, venueName *string
+	if req.VenueID != nil && strings.TrimSpace(*req.VenueID) != "" {
+		venue, err := s.venueRepo.GetVenueByID(strings.TrimSpace(*req.VenueID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get venue: %w", err)
+		}
+		if venue == nil {
+			return nil, &ValidationError{Field: "venueId", Message: "unknown venueId"}
+		}
+		venueID, venueName = &venue.ID, &venue.Name
+		// Venues are public places, so their coordinates need no fuzzing.
+		if geo.Latitude == nil {
+			geo = models.GeoLocation{Latitude: &venue.Latitude, Longitude: &venue.Longitude}
+		}
+		if location == nil || strings.TrimSpace(*location) == "" {
+			location = &venue.Name
+		}
+	}
+
 	post := &models.BeerPost{
 		UserID:               userID,
 		Username:             user.Username,
 		UserProfileImageData: user.ProfileImageData,
 		Caption:              req.Caption,
 		ImageData:            req.ImageData,
-		Location:             req.Location,
+		Location:             location,
+		Visibility:           visibility,
+		VenueID:              venueID,
+		VenueName:            venueName,
+		BeerDetails:          beer,
+		GeoLocation:          geo,
 		Comments:             []models.Comment{},
+		Reactions:            map[models.ReactionType]int{},
+		UserReactions:        []models.ReactionType{},
 	}
 
 	log.Println("[PostService] Calling repository to create post")
@@ -64,9 +146,137 @@ func (s *postService) CreatePost(userID string, req *models.CreatePostRequest) (
 	}
 
 	log.Printf("[PostService] Post created successfully with ID: %s", post.ID)
+	s.updateStreak(user, post.Timestamp)
+	s.bus.Publish(events.PostCreated{Post: *post})
 	return post, nil
 }
 
+// normalizeBeerDetails trims the free-text beer fields, turning blank values
+// into nil, and validates the rest.
+func normalizeBeerDetails(beer models.BeerDetails) (models.BeerDetails, error) {
+	textFields := []struct {
+		field  string
+		value  **string
+		maxLen int
+	}{
+		{"beerName", &beer.BeerName, 100},
+		{"brewery", &beer.Brewery, 100},
+		{"beerStyle", &beer.BeerStyle, 60},
+	}
+	for _, f := range textFields {
+		if *f.value == nil {
+			continue
+		}
+		trimmed := strings.TrimSpace(**f.value)
+		if trimmed == "" {
+			*f.value = nil
+			continue
+		}
+		if utf8.RuneCountInString(trimmed) > f.maxLen {
+			return beer, &ValidationError{Field: f.field, Message: fmt.Sprintf("%s must be at most %d characters", f.field, f.maxLen)}
+		}
+		*f.value = &trimmed
+	}
+
+	if beer.ABV != nil && (*beer.ABV < 0 || *beer.ABV > 70) {
+		return beer, &ValidationError{Field: "abv", Message: "abv must be between 0 and 70"}
+	}
+	if beer.ServingType != nil && !beer.ServingType.IsValid() {
+		return beer, &ValidationError{Field: "servingType", Message: "servingType must be DRAFT, BOTTLE or CAN"}
+	}
+	if beer.Rating != nil && (*beer.Rating < 1 || *beer.Rating > 5) {
+		return beer, &ValidationError{Field: "rating", Message: "rating must be between 1 and 5"}
+	}
+
+	return beer, nil
+}
+
+// normalizeGeoLocation validates the coordinates and fuzzes them for privacy.
+func (s *postService) normalizeGeoLocation(geo models.GeoLocation) (models.GeoLocation, error) {
+	if geo.Latitude == nil && geo.Longitude == nil {
+		return models.GeoLocation{}, nil
+	}
+	if geo.Latitude == nil || geo.Longitude == nil {
+		return geo, &ValidationError{Field: "latitude", Message: "latitude and longitude must be provided together"}
+	}
+
+	lat, lng := *geo.Latitude, *geo.Longitude
+	if math.IsNaN(lat) || lat < -90 || lat > 90 {
+		return geo, &ValidationError{Field: "latitude", Message: "latitude must be between -90 and 90"}
+	}
+	if math.IsNaN(lng) || lng < -180 || lng > 180 {
+		return geo, &ValidationError{Field: "longitude", Message: "longitude must be between -180 and 180"}
+	}
+	accuracy := 0.0
+	if geo.LocationAccuracy != nil {
+		accuracy = *geo.LocationAccuracy
+		if math.IsNaN(accuracy) || accuracy < 0 {
+			return geo, &ValidationError{Field: "locationAccuracy", Message: "locationAccuracy must not be negative"}
+		}
+	}
+
+	lat, lng, fuzzRadius := s.fuzzer.Apply(lat, lng)
+	accuracy += fuzzRadius
+
+	result := models.GeoLocation{Latitude: &lat, Longitude: &lng}
+	if geo.LocationAccuracy != nil || fuzzRadius > 0 {
+		result.LocationAccuracy = &accuracy
+	}
+	return result, nil
+}
+
+// linkCatalogBeer resolves the post's beer against the catalog, either by the
+// explicit beerId or by matching the free-text name against known aliases.
+// Linked posts use the catalog's canonical beer and brewery names.
+func (s *postService) linkCatalogBeer(beer *models.BeerDetails) error {
+	var match *models.Beer
+	var err error
+
+	if beer.BeerID != nil && strings.TrimSpace(*beer.BeerID) != "" {
+		match, err = s.catalogRepo.GetBeerByID(strings.TrimSpace(*beer.BeerID))
+		if err != nil {
+			return fmt.Errorf("failed to get beer: %w", err)
+		}
+		if match == nil {
+			return &ValidationError{Field: "beerId", Message: "unknown beerId"}
+		}
+	} else if beer.BeerName != nil {
+		beer.BeerID = nil
+		candidates := []string{*beer.BeerName}
+		if beer.Brewery != nil {
+			candidates = append(candidates, *beer.Brewery+" "+*beer.BeerName)
+		}
+		for _, candidate := range candidates {
+			match, err = s.catalogRepo.FindBeerByAlias(normalizeName(candidate))
+			if err != nil {
+				return fmt.Errorf("failed to match beer: %w", err)
+			}
+			if match != nil {
+				break
+			}
+		}
+	} else {
+		beer.BeerID = nil
+	}
+
+	if match == nil {
+		return nil
+	}
+
+	beer.BeerID = &match.ID
+	beer.BeerName = &match.Name
+	if match.BreweryName != nil {
+		beer.Brewery = match.BreweryName
+	}
+	if beer.BeerStyle == nil {
+		beer.BeerStyle = match.Style
+	}
+	if beer.ABV == nil {
+		beer.ABV = match.ABV
+	}
+	return nil
+}
+
 func (s *postService) GetPostByID(postID string, userID string) (*models.BeerPost, error) {
 	log.Printf("[PostService] GetPostByID called - postID: %s, userID: %s", postID, userID)
 	post, err := s.repo.GetPostByID(postID, userID)
@@ -132,6 +342,40 @@ func (s *postService) GetUserPosts(targetUserID string, currentUserID string, pa
 	}, nil
 }
 
+// GetNearbyPosts returns posts within radiusKm of the point for the map. If
+// hours is positive only posts from that many past hours are included.
+func (s *postService) GetNearbyPosts(userID string, lat, lng, radiusKm float64, hours, limit int) (*models.GetNearbyPostsResponse, error) {
+	if math.IsNaN(lat) || lat < -90 || lat > 90 {
+		return nil, &ValidationError{Field: "lat", Message: "lat must be between -90 and 90"}
+	}
+	if math.IsNaN(lng) || lng < -180 || lng > 180 {
+		return nil, &ValidationError{Field: "lng", Message: "lng must be between -180 and 180"}
+	}
+	if math.IsNaN(radiusKm) || radiusKm <= 0 {
+		radiusKm = defaultNearbyRadiusKm
+	}
+	if radiusKm > maxNearbyRadiusKm {
+		return nil, &ValidationError{Field: "radiusKm", Message: fmt.Sprintf("radiusKm must be at most %d", maxNearbyRadiusKm)}
+	}
+	if hours > maxNearbyHours {
+		hours = maxNearbyHours
+	}
+	if limit < 1 || limit > maxNearbyLimit {
+		limit = defaultNearbyLimit
+	}
+
+	since := time.Time{}
+	if hours > 0 {
+		since = time.Now().Add(-time.Duration(hours) * time.Hour)
+	}
+
+	posts, err := s.repo.GetNearbyPosts(userID, lat, lng, radiusKm, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nearby posts: %w", err)
+	}
+	return &models.GetNearbyPostsResponse{Posts: posts}, nil
+}
+
 func (s *postService) EnsureUserExists(userID, email, username string) error {
 	user, err := s.repo.GetUserByID(userID)
 	if err != nil {
@@ -160,6 +404,9 @@ func (s *postService) VotePost(userID string, req *models.VoteRequest) (*models.
 	if post == nil {
 		return nil, fmt.Errorf("post not found")
 	}
+	if err := s.checkNotBlocked(post.UserID, userID); err != nil {
+		return nil, err
+	}
 
 	// Check if user already voted
 	existingVote, err := s.repo.GetVoteByUserAndPost(userID, req.PostID)
@@ -167,82 +414,93 @@ func (s *postService) VotePost(userID string, req *models.VoteRequest) (*models.
 		return nil, fmt.Errorf("failed to check existing vote: %w", err)
 	}
 
-	var scoreChange int
-	var upvoteChange, downvoteChange int
+	var previous models.VoteType
+	var vote *models.Vote
+	var voteRemoved bool
 
 	if existingVote != nil {
+		vote = existingVote
+		previous = existingVote.VoteType
 		if existingVote.VoteType == req.VoteType {
 			// Remove vote (toggle off)
-			if err := s.repo.DeleteVote(existingVote.ID); err != nil {
-				return nil, fmt.Errorf("failed to delete vote: %w", err)
-			}
-			if req.VoteType == models.VoteTypeUpvote {
-				scoreChange = -1
-				upvoteChange = -1
-			} else {
-				scoreChange = 1
-				downvoteChange = -1
-			}
+			voteRemoved = true
 		} else {
 			// Change vote type
 			existingVote.VoteType = req.VoteType
-			existingVote.UpdatedAt = time.Now()
-			if err := s.repo.UpdateVote(existingVote); err != nil {
-				return nil, fmt.Errorf("failed to update vote: %w", err)
-			}
-			if req.VoteType == models.VoteTypeUpvote {
-				scoreChange = 2 // -(-1) + 1 = 2
-				upvoteChange = 1
-				downvoteChange = -1
-			} else {
-				scoreChange = -2 // -(1) + (-1) = -2
-				upvoteChange = -1
-				downvoteChange = 1
-			}
+			existingVote.UpdatedAt = time.Now().UTC()
 		}
 	} else {
-		// New vote
-		newVote := &models.Vote{
+		// New vote. Times are kept in UTC so that vote cursors compare
+		// consistently.
+		now := time.Now().UTC()
+		vote = &models.Vote{
 			ID:        uuid.New().String(),
 			PostID:    req.PostID,
 			UserID:    userID,
 			VoteType:  req.VoteType,
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-		}
-		if err := s.repo.AddVote(newVote); err != nil {
-			return nil, fmt.Errorf("failed to add vote: %w", err)
-		}
-		if req.VoteType == models.VoteTypeUpvote {
-			scoreChange = 1
-			upvoteChange = 1
-		} else {
-			scoreChange = -1
-			downvoteChange = 1
+			CreatedAt: now,
+			UpdatedAt: now,
 		}
 	}
 
-	// Update post vote counts
-	newUpvotes := post.Upvotes + upvoteChange
-	newDownvotes := post.Downvotes + downvoteChange
-	if err := s.repo.UpdatePostVotes(post.ID, newUpvotes, newDownvotes); err != nil {
-		return nil, fmt.Errorf("failed to update post votes: %w", err)
+	current := req.VoteType
+	if voteRemoved {
+		current = ""
+	}
+	upvoteChange, downvoteChange := voteCountChanges(previous, current)
+
+	event := events.VoteCast{
+		VoteID:   vote.ID,
+		PostID:   post.ID,
+		AuthorID: post.UserID,
+		VoterID:  userID,
+		VoteType: req.VoteType,
+		Previous: previous,
+		Removed:  voteRemoved,
 	}
 
-	// Update user taste score (author of the post)
-	if scoreChange != 0 {
-		if err := s.userRepo.UpdateTasteScore(post.UserID, scoreChange); err != nil {
-			log.Printf("[PostService] ERROR: Failed to update taste score for user %s: %v", post.UserID, err)
-			// Don't fail the request if taste score update fails
-		}
+	// The event is also stored in the outbox with the vote, so that durable
+	// side effects such as the author's taste score are applied even if the
+	// first attempt fails
+	message, err := newOutboxMessage(event)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update vote and post vote counts
+	upvotes, downvotes, saved, err := s.repo.SaveVote(vote, previous, voteRemoved, upvoteChange, downvoteChange, []models.OutboxMessage{*message})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save vote: %w", err)
+	}
+	// When a concurrent request changed the vote first, this one has no
+	// effect and the current counts are returned
+	if saved {
+		s.outbox.Wake()
+		event.Upvotes, event.Downvotes = upvotes, downvotes
+		s.bus.Publish(event)
 	}
 
 	return &models.VoteResponse{
-		Upvotes:   newUpvotes,
-		Downvotes: newDownvotes,
+		Upvotes:   upvotes,
+		Downvotes: downvotes,
 	}, nil
 }
 
+// voteCountChanges returns how a post's upvote and downvote counts change when
+// a voter's vote goes from previous to current. Either may be empty for no
+// vote.
+func voteCountChanges(previous, current models.VoteType) (upvoteChange, downvoteChange int) {
+	count := func(voteType, counted models.VoteType) int {
+		if voteType == counted {
+			return 1
+		}
+		return 0
+	}
+	upvoteChange = count(current, models.VoteTypeUpvote) - count(previous, models.VoteTypeUpvote)
+	downvoteChange = count(current, models.VoteTypeDownvote) - count(previous, models.VoteTypeDownvote)
+	return upvoteChange, downvoteChange
+}
+
 func (s *postService) AddComment(userID string, req *models.AddCommentRequest) (*models.Comment, error) {
 	// Check if post exists
 	post, err := s.repo.GetPostByID(req.PostID, userID)
@@ -252,6 +510,9 @@ func (s *postService) AddComment(userID string, req *models.AddCommentRequest) (
 	if post == nil {
 		return nil, fmt.Errorf("post not found")
 	}
+	if err := s.checkNotBlocked(post.UserID, userID); err != nil {
+		return nil, err
+	}
 
 	user, err := s.userRepo.GetUserByID(userID)
 	if err != nil {
@@ -274,5 +535,36 @@ func (s *postService) AddComment(userID string, req *models.AddCommentRequest) (
 		return nil, fmt.Errorf("failed to add comment: %w", err)
 	}
 
+	s.bus.Publish(events.CommentAdded{PostAuthorID: post.UserID, Comment: *comment})
 	return comment, nil
 }
+
+// updateStreak extends the author's posting streak after a post. Failures are
+// logged and don't fail the request.
+func (s *postService) updateStreak(user *models.User, postTime time.Time) {
+	userStreak := models.UserStreak{
+		UserID:        user.ID,
+		TimeZone:      user.TimeZone,
+		CurrentStreak: user.CurrentStreak,
+		LongestStreak: user.LongestStreak,
+		LastPostDate:  user.LastPostDate,
+	}
+	if !streak.Advance(&userStreak, postTime) {
+		return
+	}
+	if err := s.userRepo.UpdateStreak(&userStreak); err != nil {
+		log.Printf("[PostService] ERROR: Failed to update streak for user %s: %v", user.ID, err)
+	}
+}
+
+// checkNotBlocked returns ErrBlocked if authorID has blocked userID.
+func (s *postService) checkNotBlocked(authorID, userID string) error {
+	restriction, err := s.relationRepo.GetRestriction(authorID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to check block status: %w", err)
+	}
+	if restriction != nil && restriction.Type == models.RestrictionTypeBlock {
+		return ErrBlocked
+	}
+	return nil
+}
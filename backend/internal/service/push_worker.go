@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/batku/beerreal/internal/models"
+	"github.com/batku/beerreal/internal/push"
+	"github.com/batku/beerreal/internal/repository"
+)
+
+const (
+	pushQueueSize   = 1000
+	pushSendTimeout = 10 * time.Second
+)
+
+// pushJob is a message waiting to be sent to one device, or to every device
+// of the user when the token is empty.
+type pushJob struct {
+	userID  string
+	message push.Message
+	attempt int
+}
+
+// PushWorker delivers notifications to users' devices in the background.
+// Temporary failures are retried with exponential backoff and tokens the
+// push service rejects are removed.
+type PushWorker struct {
+	devices     repository.DeviceRepository
+	sender      push.Sender
+	jobs        chan pushJob
+	maxAttempts int
+	retryDelay  time.Duration
+}
+
+func NewPushWorker(devices repository.DeviceRepository, sender push.Sender, maxAttempts int, retryDelay time.Duration) *PushWorker {
+	return &PushWorker{
+		devices:     devices,
+		sender:      sender,
+		jobs:        make(chan pushJob, pushQueueSize),
+		maxAttempts: maxAttempts,
+		retryDelay:  retryDelay,
+	}
+}
+
+// Start runs the given number of delivery goroutines.
+func (w *PushWorker) Start(workers int) {
+	for i := 0; i < workers; i++ {
+		go func() {
+			for job := range w.jobs {
+				w.process(job)
+			}
+		}()
+	}
+}
+
+// Dispatch queues a push message for the notification's recipient.
+func (w *PushWorker) Dispatch(notification models.Notification) {
+	w.enqueue(pushJob{userID: notification.UserID, message: pushMessageFor(notification)})
+}
+
+// enqueue adds the job without blocking; jobs are dropped when the queue is
+// full so that requests are never held up by push delivery.
+func (w *PushWorker) enqueue(job pushJob) {
+	select {
+	case w.jobs <- job:
+	default:
+		log.Printf("[PushWorker] ERROR: Queue full, dropping push for user %s", job.userID)
+	}
+}
+
+func (w *PushWorker) process(job pushJob) {
+	if job.message.Token != "" {
+		w.send(job)
+		return
+	}
+
+	tokens, err := w.devices.GetDeviceTokens(job.userID)
+	if err != nil {
+		w.retry(job, err)
+		return
+	}
+	for _, token := range tokens {
+		deviceJob := job
+		deviceJob.message.Token = token
+		w.send(deviceJob)
+	}
+}
+
+func (w *PushWorker) send(job pushJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), pushSendTimeout)
+	defer cancel()
+
+	err := w.sender.Send(ctx, job.message)
+	if err == nil {
+		return
+	}
+	if errors.Is(err, push.ErrInvalidToken) {
+		log.Printf("[PushWorker] Removing invalid token for user %s: %v", job.userID, err)
+		if err := w.devices.DeleteToken(job.message.Token); err != nil {
+			log.Printf("[PushWorker] ERROR: Failed to remove token: %v", err)
+		}
+		return
+	}
+	w.retry(job, err)
+}
+
+// retry schedules the job again after retryDelay * 2^attempt, or gives up
+// after maxAttempts.
+func (w *PushWorker) retry(job pushJob, err error) {
+	job.attempt++
+	if job.attempt >= w.maxAttempts {
+		log.Printf("[PushWorker] ERROR: Giving up on push for user %s after %d attempts: %v", job.userID, job.attempt, err)
+		return
+	}
+
+	delay := w.retryDelay << (job.attempt - 1)
+	log.Printf("[PushWorker] Push for user %s failed (attempt %d), retrying in %s: %v", job.userID, job.attempt, delay, err)
+	time.AfterFunc(delay, func() { w.enqueue(job) })
+}
+
+// pushMessageFor renders the notification as a push message; the data lets
+// the app open the right screen.
+func pushMessageFor(n models.Notification) push.Message {
+	actor := n.ActorUsername
+	if actor == "" {
+		actor = "Someone"
+	}
+
+	message := push.Message{
+		Title: "BeerReal",
+		Data: map[string]string{
+			"type": string(n.Type),
+		},
+	}
+	// Daily moments are not stored, so they have no ID
+	if n.ID != "" {
+		message.Data["notificationId"] = n.ID
+	}
+	if n.PostID != nil {
+		message.Data["postId"] = *n.PostID
+	}
+
+	text := ""
+	if n.Text != nil {
+		text = *n.Text
+	}
+	switch n.Type {
+	case models.NotificationTypeVote:
+		if n.VoteType != nil && *n.VoteType == models.VoteTypeDownvote {
+			message.Body = fmt.Sprintf("%s downvoted your post", actor)
+		} else {
+			message.Body = fmt.Sprintf("%s upvoted your post", actor)
+		}
+	case models.NotificationTypeComment:
+		message.Body = fmt.Sprintf("%s commented: %s", actor, text)
+	case models.NotificationTypeMention:
+		message.Body = fmt.Sprintf("%s mentioned you: %s", actor, text)
+	case models.NotificationTypeFriendRequest:
+		message.Body = fmt.Sprintf("%s sent you a friend request", actor)
+	case models.NotificationTypeFriendAccepted:
+		message.Body = fmt.Sprintf("%s accepted your friend request", actor)
+	case models.NotificationTypeDailyMoment:
+		message.Title = "⚠️ Time to BeerReal ⚠️"
+		message.Body = "Share what you're drinking right now!"
+	default:
+		message.Body = "You have a new notification"
+	}
+	return message
+}
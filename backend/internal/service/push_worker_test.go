@@ -0,0 +1,135 @@
+package service
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/batku/beerreal/internal/database"
+	"github.com/batku/beerreal/internal/models"
+	"github.com/batku/beerreal/internal/push"
+	"github.com/batku/beerreal/internal/repository"
+)
+
+// newTestDB opens a fresh database in the test's temp directory.
+func newTestDB(t *testing.T) *database.Database {
+	t.Helper()
+	db, err := database.NewDatabase(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// addUserWithDevices creates a user in UTC with the given device tokens.
+func addUserWithDevices(t *testing.T, db *database.Database, userID string, tokens ...string) {
+	t.Helper()
+	now := time.Now()
+	err := repository.NewUserRepository(db.DB).CreateOrUpdateUser(&models.User{
+		ID:         userID,
+		Username:   userID,
+		Email:      userID + "@example.com",
+		JoinedDate: now,
+		TimeZone:   "UTC",
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	})
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	devices := repository.NewDeviceRepository(db.DB)
+	for _, token := range tokens {
+		device := &models.Device{Token: token, UserID: userID, Platform: models.DevicePlatformAndroid}
+		if err := devices.RegisterDevice(device); err != nil {
+			t.Fatalf("failed to register device: %v", err)
+		}
+	}
+}
+
+// waitFor polls cond until it holds or a second has passed.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestPushWorkerRetriesTemporaryFailures(t *testing.T) {
+	db := newTestDB(t)
+	addUserWithDevices(t, db, "alice", "token-1")
+
+	sender := push.NewFakeSender()
+	sender.FailNext = 2
+	worker := NewPushWorker(repository.NewDeviceRepository(db.DB), sender, 3, time.Millisecond)
+	worker.Start(1)
+
+	worker.Dispatch(models.Notification{ID: "n1", UserID: "alice", Type: models.NotificationTypeFriendRequest, ActorUsername: "bob"})
+
+	waitFor(t, "the push to be sent", func() bool { return len(sender.Sent()) == 1 })
+	if attempts := sender.Attempts(); attempts != 3 {
+		t.Errorf("attempts = %d, want 3", attempts)
+	}
+	message := sender.Sent()[0]
+	if message.Token != "token-1" {
+		t.Errorf("token = %q, want token-1", message.Token)
+	}
+	if message.Data["notificationId"] != "n1" {
+		t.Errorf("notificationId = %q, want n1", message.Data["notificationId"])
+	}
+}
+
+func TestPushWorkerGivesUpAfterMaxAttempts(t *testing.T) {
+	db := newTestDB(t)
+	addUserWithDevices(t, db, "alice", "token-1")
+
+	sender := push.NewFakeSender()
+	sender.FailNext = 10
+	worker := NewPushWorker(repository.NewDeviceRepository(db.DB), sender, 3, time.Millisecond)
+	worker.Start(1)
+
+	worker.Dispatch(models.Notification{ID: "n1", UserID: "alice", Type: models.NotificationTypeFriendRequest})
+
+	waitFor(t, "three attempts", func() bool { return sender.Attempts() >= 3 })
+	// Give a fourth attempt the time it would need to happen
+	time.Sleep(50 * time.Millisecond)
+	if attempts := sender.Attempts(); attempts != 3 {
+		t.Errorf("attempts = %d, want 3", attempts)
+	}
+	if sent := len(sender.Sent()); sent != 0 {
+		t.Errorf("sent %d messages, want 0", sent)
+	}
+}
+
+func TestPushWorkerRemovesInvalidTokens(t *testing.T) {
+	db := newTestDB(t)
+	addUserWithDevices(t, db, "alice", "valid", "stale")
+	devices := repository.NewDeviceRepository(db.DB)
+
+	sender := push.NewFakeSender()
+	sender.InvalidTokens["stale"] = true
+	worker := NewPushWorker(devices, sender, 3, time.Millisecond)
+	worker.Start(1)
+
+	worker.Dispatch(models.Notification{ID: "n1", UserID: "alice", Type: models.NotificationTypeFriendRequest})
+
+	waitFor(t, "the stale token to be removed", func() bool {
+		tokens, err := devices.GetDeviceTokens("alice")
+		return err == nil && len(tokens) == 1
+	})
+	tokens, err := devices.GetDeviceTokens("alice")
+	if err != nil {
+		t.Fatalf("failed to get tokens: %v", err)
+	}
+	if tokens[0] != "valid" {
+		t.Errorf("remaining token = %q, want valid", tokens[0])
+	}
+	waitFor(t, "the push to the valid token", func() bool { return len(sender.Sent()) == 1 })
+	if token := sender.Sent()[0].Token; token != "valid" {
+		t.Errorf("sent to %q, want valid", token)
+	}
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/batku/beerreal/internal/models"
+	"github.com/batku/beerreal/internal/repository"
+)
+
+// maxRealMojiImageDataLength caps the size of a RealMoji data URI. RealMojis
+// are shown as small thumbnails, so they are kept well below post images.
+const maxRealMojiImageDataLength = 512 << 10
+
+var (
+	ErrRealMojiNotFound = errors.New("RealMoji not found")
+	// ErrNotRealMojiOwner is returned when someone other than the RealMoji's
+	// owner or the post's author tries to delete it.
+	ErrNotRealMojiOwner = errors.New("only the owner or the post's author can delete this RealMoji")
+)
+
+type ReactionService struct {
+	repo         repository.ReactionRepository
+	postRepo     repository.PostRepository
+	relationRepo repository.RelationshipRepository
+}
+
+func NewReactionService(repo repository.ReactionRepository, postRepo repository.PostRepository, relationRepo repository.RelationshipRepository) *ReactionService {
+	return &ReactionService{repo: repo, postRepo: postRepo, relationRepo: relationRepo}
+}
+
+// AddReaction adds the user's reaction to a post they can see and returns the
+// post's updated reactions.
+func (s *ReactionService) AddReaction(userID, postID string, reactionType models.ReactionType) (*models.PostReactionsResponse, error) {
+	if !reactionType.IsValid() {
+		return nil, invalidReactionType()
+	}
+	post, err := s.getVisiblePost(postID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	restriction, err := s.relationRepo.GetRestriction(post.UserID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check block status: %w", err)
+	}
+	if restriction != nil && restriction.Type == models.RestrictionTypeBlock {
+		return nil, ErrBlocked
+	}
+
+	if err := s.repo.AddReaction(postID, userID, reactionType); err != nil {
+		return nil, err
+	}
+	return s.repo.GetReactionSummary(postID, userID)
+}
+
+// RemoveReaction takes back the user's reaction and returns the post's
+// updated reactions.
+func (s *ReactionService) RemoveReaction(userID, postID string, reactionType models.ReactionType) (*models.PostReactionsResponse, error) {
+	if !reactionType.IsValid() {
+		return nil, invalidReactionType()
+	}
+	if _, err := s.getVisiblePost(postID, userID); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.RemoveReaction(postID, userID, reactionType); err != nil {
+		return nil, err
+	}
+	return s.repo.GetReactionSummary(postID, userID)
+}
+
+// GetReactions lists who reacted to a post, optionally only with one type.
+func (s *ReactionService) GetReactions(postID, viewerID string, reactionType models.ReactionType, page, pageSize int) (*models.GetReactionsResponse, error) {
+	if reactionType != "" && !reactionType.IsValid() {
+		return nil, invalidReactionType()
+	}
+	if _, err := s.getVisiblePost(postID, viewerID); err != nil {
+		return nil, err
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	reactions, totalCount, err := s.repo.GetReactions(postID, viewerID, reactionType, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.GetReactionsResponse{
+		Reactions:  reactions,
+		TotalCount: totalCount,
+		Page:       page,
+		PageSize:   pageSize,
+	}, nil
+}
+
+// AddRealMoji stores the user's selfie reaction to a post they can see,
+// replacing their earlier one.
+func (s *ReactionService) AddRealMoji(userID, postID string, req *models.CreateRealMojiRequest) (*models.RealMoji, error) {
+	if err := validateImageData("imageData", "image", req.ImageData, maxRealMojiImageDataLength); err != nil {
+		return nil, err
+	}
+
+	post, err := s.getVisiblePost(postID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	restriction, err := s.relationRepo.GetRestriction(post.UserID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check block status: %w", err)
+	}
+	if restriction != nil && restriction.Type == models.RestrictionTypeBlock {
+		return nil, ErrBlocked
+	}
+
+	realMoji := &models.RealMoji{PostID: postID, UserID: userID, ImageData: req.ImageData}
+	if err := s.repo.SaveRealMoji(realMoji); err != nil {
+		return nil, err
+	}
+	return s.repo.GetRealMojiByID(realMoji.ID)
+}
+
+// GetRealMojis lists the selfie reactions to a post, newest first.
+func (s *ReactionService) GetRealMojis(postID, viewerID string, page, pageSize int) (*models.GetRealMojisResponse, error) {
+	if _, err := s.getVisiblePost(postID, viewerID); err != nil {
+		return nil, err
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	realMojis, totalCount, err := s.repo.GetRealMojis(postID, viewerID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.GetRealMojisResponse{
+		RealMojis:  realMojis,
+		TotalCount: totalCount,
+		Page:       page,
+		PageSize:   pageSize,
+	}, nil
+}
+
+// DeleteRealMoji removes a RealMoji. Users can delete their own, and authors
+// can delete any RealMoji on their posts.
+func (s *ReactionService) DeleteRealMoji(userID, postID, realMojiID string) error {
+	realMoji, err := s.repo.GetRealMojiByID(realMojiID)
+	if err != nil {
+		return err
+	}
+	if realMoji == nil || realMoji.PostID != postID {
+		return ErrRealMojiNotFound
+	}
+
+	if realMoji.UserID != userID {
+		post, err := s.postRepo.GetPostByID(postID, userID)
+		if err != nil && !errors.Is(err, repository.ErrPostNotFound) {
+			return fmt.Errorf("failed to get post: %w", err)
+		}
+		if post == nil || post.UserID != userID {
+			return ErrNotRealMojiOwner
+		}
+	}
+
+	return s.repo.DeleteRealMoji(realMojiID)
+}
+
+// getVisiblePost returns ErrPostNotFound unless the viewer can see the post.
+func (s *ReactionService) getVisiblePost(postID, viewerID string) (*models.BeerPost, error) {
+	post, err := s.postRepo.GetPostByID(postID, viewerID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPostNotFound) {
+			return nil, ErrPostNotFound
+		}
+		return nil, fmt.Errorf("failed to get post: %w", err)
+	}
+	if post == nil {
+		return nil, ErrPostNotFound
+	}
+	return post, nil
+}
+
+func invalidReactionType() error {
+	return &ValidationError{Field: "type", Message: "type must be CHEERS, BEER, SICK, LAUGH, LOVE or FIRE"}
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"log"
+	"time"
+
+	"github.com/batku/beerreal/internal/streak"
+)
+
+// ResetBrokenStreaks zeroes the current streak of every user who has not
+// posted since the day before yesterday in their time zone and returns how
+// many streaks were reset.
+func (s *UserService) ResetBrokenStreaks(now time.Time) (int, error) {
+	streaks, err := s.repo.GetActiveStreaks()
+	if err != nil {
+		return 0, err
+	}
+
+	var broken []string
+	for _, userStreak := range streaks {
+		if streak.Broken(userStreak, now) {
+			broken = append(broken, userStreak.UserID)
+		}
+	}
+	if len(broken) == 0 {
+		return 0, nil
+	}
+
+	if err := s.repo.ResetStreaks(broken); err != nil {
+		return 0, err
+	}
+	return len(broken), nil
+}
+
+// RunStreakResetJob resets broken streaks every night at the given UTC hour.
+// It blocks forever, so run it in its own goroutine.
+func (s *UserService) RunStreakResetJob(hourUTC int) {
+	for {
+		now := time.Now().UTC()
+		next := time.Date(now.Year(), now.Month(), now.Day(), hourUTC, 0, 0, 0, time.UTC)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		time.Sleep(time.Until(next))
+
+		reset, err := s.ResetBrokenStreaks(time.Now())
+		if err != nil {
+			log.Printf("[UserService] ERROR: Streak reset failed: %v", err)
+			continue
+		}
+		log.Printf("[UserService] Reset %d broken streaks", reset)
+	}
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/batku/beerreal/internal/events"
+	"github.com/batku/beerreal/internal/models"
+	"github.com/batku/beerreal/internal/repository"
+)
+
+const (
+	maxStreamPosts   = 100
+	streamBufferSize = 32
+)
+
+// StreamConnection is one client listening for real-time updates. Events are
+// dropped rather than queued without bound if the client falls behind.
+type StreamConnection struct {
+	UserID string
+	Events chan models.StreamEvent
+
+	mu    sync.Mutex
+	posts map[string]bool
+}
+
+// PostIDs returns the posts the connection receives updates for.
+func (c *StreamConnection) PostIDs() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ids := make([]string, 0, len(c.posts))
+	for id := range c.posts {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (c *StreamConnection) watches(postID string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.posts[postID]
+}
+
+func (c *StreamConnection) watch(postID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.posts) < maxStreamPosts {
+		c.posts[postID] = true
+	}
+}
+
+// StreamService forwards events from the bus to connected clients: new posts
+// and profile changes of friends, and vote counts and comments of posts the
+// client watches or authored.
+type StreamService struct {
+	postRepo     repository.PostRepository
+	relationRepo repository.RelationshipRepository
+
+	mu          sync.RWMutex
+	connections map[*StreamConnection]bool
+}
+
+// NewStreamService subscribes the service to the bus. Delivery is
+// asynchronous so that the visibility checks below never slow down the
+// request that published the event.
+func NewStreamService(bus *events.Bus, postRepo repository.PostRepository, relationRepo repository.RelationshipRepository) *StreamService {
+	s := &StreamService{
+		postRepo:     postRepo,
+		relationRepo: relationRepo,
+		connections:  map[*StreamConnection]bool{},
+	}
+	bus.Subscribe("StreamService", events.Async, s.handle)
+	return s
+}
+
+// Connect registers a client that watches the given posts. Posts the user
+// cannot see are ignored.
+func (s *StreamService) Connect(userID string, postIDs []string) (*StreamConnection, error) {
+	if len(postIDs) > maxStreamPosts {
+		return nil, &ValidationError{Field: "posts", Message: fmt.Sprintf("at most %d posts can be watched", maxStreamPosts)}
+	}
+
+	conn := &StreamConnection{
+		UserID: userID,
+		Events: make(chan models.StreamEvent, streamBufferSize),
+		posts:  map[string]bool{},
+	}
+	for _, id := range postIDs {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		post, err := s.postRepo.GetPostByID(id, userID)
+		if err != nil && !errors.Is(err, repository.ErrPostNotFound) {
+			return nil, fmt.Errorf("failed to get post: %w", err)
+		}
+		if post != nil {
+			conn.posts[id] = true
+		}
+	}
+
+	s.mu.Lock()
+	s.connections[conn] = true
+	s.mu.Unlock()
+	return conn, nil
+}
+
+// Disconnect unregisters the client and closes its event channel.
+func (s *StreamService) Disconnect(conn *StreamConnection) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.connections[conn] {
+		delete(s.connections, conn)
+		close(conn.Events)
+	}
+}
+
+func (s *StreamService) handle(event events.Event) {
+	switch e := event.(type) {
+	case events.PostCreated:
+		s.sendPost(e.Post)
+	case events.VoteCast:
+		update := models.PostVotesUpdate{PostID: e.PostID, Upvotes: e.Upvotes, Downvotes: e.Downvotes}
+		s.send(func(conn *StreamConnection) bool {
+			return conn.UserID == e.AuthorID || conn.watches(e.PostID)
+		}, models.StreamEvent{Type: models.StreamEventVotes, Data: update})
+	case events.CommentAdded:
+		s.send(func(conn *StreamConnection) bool {
+			if conn.UserID == e.Comment.UserID {
+				return false
+			}
+			if conn.UserID != e.PostAuthorID && !conn.watches(e.Comment.PostID) {
+				return false
+			}
+			return !s.restricted(conn.UserID, e.Comment.UserID)
+		}, models.StreamEvent{Type: models.StreamEventComment, Data: e.Comment})
+	case events.UserUpdated:
+		s.sendUser(e.User.ToPublic())
+	}
+}
+
+// sendPost sends a new post to the author's friends who can see it, as they
+// would see it in their feed, and starts watching it for them. Like the feed,
+// it skips friends who blocked or muted the author.
+func (s *StreamService) sendPost(post models.BeerPost) {
+	for _, conn := range s.snapshot() {
+		if conn.UserID == post.UserID || !s.isFriend(conn.UserID, post.UserID) {
+			continue
+		}
+		if s.restricted(conn.UserID, post.UserID) {
+			continue
+		}
+
+		visible, err := s.postRepo.GetPostByID(post.ID, conn.UserID)
+		if err != nil {
+			if !errors.Is(err, repository.ErrPostNotFound) {
+				log.Printf("[StreamService] ERROR: Failed to check post visibility: %v", err)
+			}
+			continue
+		}
+		if visible == nil {
+			continue
+		}
+
+		conn.watch(post.ID)
+		s.deliver(conn, models.StreamEvent{Type: models.StreamEventPost, Data: visible})
+	}
+}
+
+// sendUser sends a user's changed profile to their friends who have not
+// blocked or muted them, so that names and pictures in the feed stay current.
+func (s *StreamService) sendUser(user models.PublicUser) {
+	s.send(func(conn *StreamConnection) bool {
+		if conn.UserID == user.ID || !s.isFriend(conn.UserID, user.ID) {
+			return false
+		}
+		return !s.restricted(conn.UserID, user.ID)
+	}, models.StreamEvent{Type: models.StreamEventUser, Data: user})
+}
+
+func (s *StreamService) send(match func(conn *StreamConnection) bool, event models.StreamEvent) {
+	for _, conn := range s.snapshot() {
+		if match(conn) {
+			s.deliver(conn, event)
+		}
+	}
+}
+
+// deliver queues the event for the connection unless it was disconnected in
+// the meantime.
+func (s *StreamService) deliver(conn *StreamConnection, event models.StreamEvent) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.connections[conn] {
+		return
+	}
+	select {
+	case conn.Events <- event:
+	default:
+		log.Printf("[StreamService] Client of user %s is falling behind, dropping %s event", conn.UserID, event.Type)
+	}
+}
+
+func (s *StreamService) snapshot() []*StreamConnection {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	conns := make([]*StreamConnection, 0, len(s.connections))
+	for conn := range s.connections {
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
+// isFriend reports whether the users are friends.
+func (s *StreamService) isFriend(userID, otherUserID string) bool {
+	friendship, err := s.relationRepo.GetFriendship(userID, otherUserID)
+	if err != nil {
+		log.Printf("[StreamService] ERROR: Failed to get friendship: %v", err)
+		return false
+	}
+	return friendship != nil && friendship.Status == models.FriendshipStatusAccepted
+}
+
+// restricted reports whether the user has blocked or muted the other user.
+func (s *StreamService) restricted(userID, otherUserID string) bool {
+	restriction, err := s.relationRepo.GetRestriction(userID, otherUserID)
+	if err != nil {
+		log.Printf("[StreamService] ERROR: Failed to check restrictions: %v", err)
+		return true
+	}
+	return restriction != nil
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/batku/beerreal/internal/events"
+	"github.com/batku/beerreal/internal/models"
+)
+
+// newOutboxMessage stores the event in an outbox message of the same name, so
+// the outbox handlers for it receive it at least once.
+func newOutboxMessage(event events.Event) (*models.OutboxMessage, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s event: %w", event.Name(), err)
+	}
+	return &models.OutboxMessage{Type: event.Name(), Payload: payload}, nil
+}
+
+// voteScore is what a vote counts towards the author's taste score.
+func voteScore(voteType models.VoteType) int {
+	switch voteType {
+	case models.VoteTypeUpvote:
+		return 1
+	case models.VoteTypeDownvote:
+		return -1
+	}
+	return 0
+}
+
+// ApplyTasteScoreChange is the outbox handler for VoteCast that updates the
+// author's taste score by the difference the vote made. Redelivered messages
+// are ignored.
+func (s *UserService) ApplyTasteScoreChange(message *models.OutboxMessage) error {
+	var vote events.VoteCast
+	if err := json.Unmarshal(message.Payload, &vote); err != nil {
+		return fmt.Errorf("failed to decode vote: %w", err)
+	}
+
+	current := vote.VoteType
+	if vote.Removed {
+		current = ""
+	}
+	delta := voteScore(current) - voteScore(vote.Previous)
+	if delta == 0 {
+		return nil
+	}
+
+	return s.repo.RecordTasteScoreEvent(&models.TasteScoreEvent{
+		UserID:   vote.AuthorID,
+		PostID:   vote.PostID,
+		VoterID:  vote.VoterID,
+		VoteID:   vote.VoteID,
+		Delta:    delta,
+		OutboxID: &message.ID,
+	})
+}
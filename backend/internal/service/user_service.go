@@ -1,20 +1,63 @@
 package service
 
 import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/batku/beerreal/internal/events"
 	"github.com/batku/beerreal/internal/models"
 	"github.com/batku/beerreal/internal/repository"
 )
 
+var (
+	ErrUserNotFound  = errors.New("user not found")
+	ErrUsernameTaken = errors.New("username is already taken")
+)
+
+const (
+	maxBioLength              = 300
+	maxProfileImageDataLength = 8 << 20
+
+	defaultLeaderboardLimit = 50
+	maxLeaderboardLimit     = 100
+
+	defaultTasteScoreHistoryDays = 30
+	maxTasteScoreHistoryDays     = 365
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{3,30}$`)
+
+// ValidationError reports an invalid value for a single request field.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
 type UserService struct {
-	repo repository.UserRepository
+	repo          repository.UserRepository
+	postRepo      repository.PostRepository
+	relationRepo  repository.RelationshipRepository
+	reactionRepo  repository.ReactionRepository
+	achievements  *AchievementService
+	notifications *NotificationService
+	bus           *events.Bus
 }
 
-func NewUserService(repo repository.UserRepository) *UserService {
-	return &UserService{repo: repo}
+func NewUserService(repo repository.UserRepository, postRepo repository.PostRepository, relationRepo repository.RelationshipRepository, reactionRepo repository.ReactionRepository, achievements *AchievementService, notifications *NotificationService, bus *events.Bus) *UserService {
+	return &UserService{repo: repo, postRepo: postRepo, relationRepo: relationRepo, reactionRepo: reactionRepo, achievements: achievements, notifications: notifications, bus: bus}
 }
 
 func (s *UserService) GetOrCreateUser(id, email string) (*models.User, error) {
@@ -50,6 +93,7 @@ func (s *UserService) GetOrCreateUser(id, email string) (*models.User, error) {
 		TotalPosts:   0,
 		FriendsCount: 0,
 		JoinedDate:   now,
+		TimeZone:     "UTC",
 		CreatedAt:    now,
 		UpdatedAt:    now,
 	}
@@ -68,15 +112,65 @@ func (s *UserService) UpdateUser(userID string, req *models.UpdateUserRequest) (
 		return nil, err
 	}
 	if user == nil {
-		return nil, fmt.Errorf("user not found")
+		return nil, ErrUserNotFound
 	}
 
-	if req.Username != "" {
-		user.Username = req.Username
+	if req.Username.Set {
+		if req.Username.IsNullOrEmpty() {
+			return nil, &ValidationError{Field: "username", Message: "username cannot be empty"}
+		}
+		username := strings.TrimSpace(*req.Username.Value)
+		if !usernamePattern.MatchString(username) {
+			return nil, &ValidationError{Field: "username", Message: "username must be 3-30 characters of letters, digits, '_', '.' or '-'"}
+		}
+		existing, err := s.repo.GetUserByUsername(username)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil && existing.ID != user.ID {
+			return nil, ErrUsernameTaken
+		}
+		user.Username = username
 	}
-	if req.ProfileImageData != "" {
-		val := req.ProfileImageData
-		user.ProfileImageData = &val
+
+	if req.Bio.Set {
+		if req.Bio.IsNullOrEmpty() {
+			user.Bio = nil
+		} else {
+			bio := strings.TrimSpace(*req.Bio.Value)
+			if utf8.RuneCountInString(bio) > maxBioLength {
+				return nil, &ValidationError{Field: "bio", Message: fmt.Sprintf("bio must be at most %d characters", maxBioLength)}
+			}
+			if bio == "" {
+				user.Bio = nil
+			} else {
+				user.Bio = &bio
+			}
+		}
+	}
+
+	if req.ProfileImageData.Set {
+		if req.ProfileImageData.IsNullOrEmpty() {
+			user.ProfileImageData = nil
+		} else {
+			imageData := *req.ProfileImageData.Value
+			if err := validateImageData("profileImageData", "profile image", imageData, maxProfileImageDataLength); err != nil {
+				return nil, err
+			}
+			user.ProfileImageData = &imageData
+		}
+	}
+
+	if req.IsPrivate != nil {
+		user.IsPrivate = *req.IsPrivate
+	}
+
+	if req.TimeZone != nil {
+		timeZone := strings.TrimSpace(*req.TimeZone)
+		if _, err := time.LoadLocation(timeZone); err != nil || timeZone == "" || timeZone == "Local" {
+			return nil, &ValidationError{Field: "timeZone", Message: "timeZone must be an IANA time zone name"}
+		}
+		user.TimeZone = timeZone
 	}
 
 	user.UpdatedAt = time.Now()
@@ -85,5 +179,462 @@ func (s *UserService) UpdateUser(userID string, req *models.UpdateUserRequest) (
 		return nil, err
 	}
 
+	s.bus.Publish(events.UserUpdated{User: *user})
 	return user, nil
 }
+
+func (s *UserService) GetPublicUser(userID string) (*models.PublicUser, error) {
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	public := user.ToPublic()
+	public.Achievements, err = s.achievements.GetUnlocked(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get achievements: %w", err)
+	}
+	return &public, nil
+}
+
+func (s *UserService) SearchUsers(query string, page, pageSize int) (*models.SearchUsersResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	offset := (page - 1) * pageSize
+	users, totalCount, err := s.repo.SearchUsers(strings.TrimSpace(query), pageSize, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search users: %w", err)
+	}
+
+	publicUsers := make([]models.PublicUser, 0, len(users))
+	for i := range users {
+		publicUsers = append(publicUsers, users[i].ToPublic())
+	}
+
+	return &models.SearchUsersResponse{
+		Users:      publicUsers,
+		TotalCount: totalCount,
+		Page:       page,
+		PageSize:   pageSize,
+	}, nil
+}
+
+// RestrictUser blocks or mutes targetUserID on behalf of userID.
+func (s *UserService) RestrictUser(userID, targetUserID string, restrictionType models.RestrictionType) error {
+	if userID == targetUserID {
+		return &ValidationError{Field: "userId", Message: "you cannot block or mute yourself"}
+	}
+
+	target, err := s.repo.GetUserByID(targetUserID)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		return ErrUserNotFound
+	}
+
+	err = s.relationRepo.SetRestriction(&models.UserRestriction{
+		UserID:       userID,
+		TargetUserID: targetUserID,
+		Type:         restrictionType,
+		CreatedAt:    time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Blocking someone also ends any friendship or pending request
+	if restrictionType == models.RestrictionTypeBlock {
+		return s.relationRepo.DeleteFriendship(userID, targetUserID)
+	}
+	return nil
+}
+
+// UnrestrictUser removes a block or mute. It is a no-op if none exists.
+func (s *UserService) UnrestrictUser(userID, targetUserID string, restrictionType models.RestrictionType) error {
+	return s.relationRepo.RemoveRestriction(userID, targetUserID, restrictionType)
+}
+
+func (s *UserService) GetRestrictions(userID string, restrictionType models.RestrictionType) (*models.GetRestrictionsResponse, error) {
+	restrictions, err := s.relationRepo.GetRestrictionsByUserID(userID, restrictionType)
+	if err != nil {
+		return nil, err
+	}
+	return &models.GetRestrictionsResponse{Restrictions: restrictions}, nil
+}
+
+// AddFriend sends a friend request to targetUserID, or accepts the pending
+// request targetUserID already sent to the caller.
+func (s *UserService) AddFriend(userID, targetUserID string) (*models.Friendship, error) {
+	if userID == targetUserID {
+		return nil, &ValidationError{Field: "userId", Message: "you cannot add yourself as a friend"}
+	}
+
+	target, err := s.repo.GetUserByID(targetUserID)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, ErrUserNotFound
+	}
+
+	for _, pair := range [][2]string{{userID, targetUserID}, {targetUserID, userID}} {
+		restriction, err := s.relationRepo.GetRestriction(pair[0], pair[1])
+		if err != nil {
+			return nil, err
+		}
+		if restriction != nil && restriction.Type == models.RestrictionTypeBlock {
+			return nil, ErrBlocked
+		}
+	}
+
+	existing, err := s.relationRepo.GetFriendship(userID, targetUserID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		if existing.Status == models.FriendshipStatusPending && existing.FriendID == userID {
+			if err := s.relationRepo.AcceptFriendRequest(targetUserID, userID); err != nil {
+				return nil, err
+			}
+			existing.Status = models.FriendshipStatusAccepted
+			existing.UpdatedAt = time.Now()
+			s.bus.Publish(events.FriendAccepted{AccepterID: userID, RequesterID: targetUserID})
+		}
+		return existing, nil
+	}
+
+	now := time.Now()
+	friendship := &models.Friendship{
+		UserID:    userID,
+		FriendID:  targetUserID,
+		Status:    models.FriendshipStatusPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.relationRepo.CreateFriendRequest(friendship); err != nil {
+		return nil, err
+	}
+	s.bus.Publish(events.FriendRequested{FromUserID: userID, ToUserID: targetUserID})
+	return friendship, nil
+}
+
+// RemoveFriend ends a friendship, or cancels/declines a pending request.
+func (s *UserService) RemoveFriend(userID, targetUserID string) error {
+	return s.relationRepo.DeleteFriendship(userID, targetUserID)
+}
+
+func (s *UserService) GetFriends(userID string) (*models.GetFriendsResponse, error) {
+	friends, err := s.relationRepo.GetFriends(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	publicFriends := make([]models.PublicUser, 0, len(friends))
+	for i := range friends {
+		publicFriends = append(publicFriends, friends[i].ToPublic())
+	}
+	return &models.GetFriendsResponse{Friends: publicFriends}, nil
+}
+
+func (s *UserService) GetFriendRequests(userID string) (*models.GetFriendRequestsResponse, error) {
+	requests, err := s.relationRepo.GetIncomingFriendRequests(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.GetFriendRequestsResponse{Requests: requests}, nil
+}
+
+// DeleteUser permanently removes the user and everything they created.
+func (s *UserService) DeleteUser(userID string) error {
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+
+	return s.repo.DeleteUser(userID)
+}
+
+// ExportUserData builds a ZIP archive containing a JSON dump of everything
+// stored about the user, plus their post and profile images as files.
+func (s *UserService) ExportUserData(userID string) ([]byte, error) {
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	posts, _, err := s.postRepo.GetUserPosts(userID, userID, math.MaxInt32, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get posts: %w", err)
+	}
+	comments, err := s.postRepo.GetCommentsByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	votes, err := s.postRepo.GetVotesByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	restrictions, err := s.relationRepo.GetRestrictionsByUserID(userID, "")
+	if err != nil {
+		return nil, err
+	}
+	tasteScoreEvents, err := s.repo.GetTasteScoreEvents(userID, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	achievements, err := s.achievements.GetUnlocked(userID)
+	if err != nil {
+		return nil, err
+	}
+	notificationSettings, err := s.notifications.GetSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+	reactions, err := s.reactionRepo.GetReactionsByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	realMojis, err := s.reactionRepo.GetRealMojisByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	archive := zip.NewWriter(buf)
+
+	export := models.UserDataExport{
+		ExportedAt:           time.Now(),
+		User:                 *user,
+		Posts:                make([]models.ExportPost, 0, len(posts)),
+		Comments:             make([]models.ExportComment, 0, len(comments)),
+		Votes:                votes,
+		Restrictions:         restrictions,
+		TasteScoreEvents:     tasteScoreEvents,
+		Achievements:         achievements,
+		NotificationSettings: notificationSettings,
+		Reactions:            make([]models.ExportReaction, 0, len(reactions)),
+		RealMojis:            make([]models.ExportRealMoji, 0, len(realMojis)),
+	}
+
+	if user.ProfileImageData != nil {
+		name, err := writeImageFile(archive, "images/profile", *user.ProfileImageData)
+		if err != nil {
+			return nil, err
+		}
+		if name != "" {
+			export.ProfileImageFile = name
+			export.User.ProfileImageData = nil
+		}
+	}
+
+	for _, comment := range comments {
+		export.Comments = append(export.Comments, models.ExportComment{Comment: comment})
+	}
+	for _, reaction := range reactions {
+		export.Reactions = append(export.Reactions, models.ExportReaction{Reaction: reaction})
+	}
+
+	for _, post := range posts {
+		exportPost := models.ExportPost{BeerPost: post}
+		for i := range exportPost.Comments {
+			if exportPost.Comments[i].UserID == userID {
+				exportPost.Comments[i].UserProfileImageData = nil
+			}
+		}
+		name, err := writeImageFile(archive, "images/posts/"+post.ID, post.ImageData)
+		if err != nil {
+			return nil, err
+		}
+		if name != "" {
+			exportPost.ImageFile = name
+		} else {
+			// Not a data URI, keep the original value
+			exportPost.ImageData = post.ImageData
+		}
+		export.Posts = append(export.Posts, exportPost)
+	}
+
+	for _, realMoji := range realMojis {
+		exportRealMoji := models.ExportRealMoji{RealMoji: realMoji}
+		name, err := writeImageFile(archive, "images/realmojis/"+realMoji.ID, realMoji.ImageData)
+		if err != nil {
+			return nil, err
+		}
+		if name != "" {
+			exportRealMoji.ImageFile = name
+		} else {
+			exportRealMoji.ImageData = realMoji.ImageData
+		}
+		export.RealMojis = append(export.RealMojis, exportRealMoji)
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode export: %w", err)
+	}
+	file, err := archive.Create("data.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to write export: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to write export: %w", err)
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write export: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// writeImageFile decodes a base64 data URI and stores it in the archive under
+// basePath with an extension matching its MIME type. It returns the file name,
+// or an empty string if the value is not a base64 data URI.
+func writeImageFile(archive *zip.Writer, basePath, dataURI string) (string, error) {
+	header, payload, found := strings.Cut(dataURI, ";base64,")
+	if !found || !strings.HasPrefix(header, "data:") {
+		return "", nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", nil
+	}
+
+	ext := "bin"
+	switch strings.TrimPrefix(header, "data:") {
+	case "image/jpeg", "image/jpg":
+		ext = "jpg"
+	case "image/png":
+		ext = "png"
+	case "image/webp":
+		ext = "webp"
+	}
+
+	name := basePath + "." + ext
+	file, err := archive.Create(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to write image: %w", err)
+	}
+	if _, err := file.Write(raw); err != nil {
+		return "", fmt.Errorf("failed to write image: %w", err)
+	}
+	return name, nil
+}
+
+// GetLeaderboard ranks users by taste score over the period. The friends
+// scope covers the viewer and their friends. Users with equal scores share a
+// rank.
+func (s *UserService) GetLeaderboard(viewerID string, scope models.LeaderboardScope, period models.LeaderboardPeriod, limit int) (*models.GetLeaderboardResponse, error) {
+	if scope == "" {
+		scope = models.LeaderboardScopeGlobal
+	}
+	if !scope.IsValid() {
+		return nil, &ValidationError{Field: "scope", Message: "scope must be global or friends"}
+	}
+	if period == "" {
+		period = models.LeaderboardPeriodAll
+	}
+	since, ok := leaderboardSince(period, time.Now())
+	if !ok {
+		return nil, &ValidationError{Field: "period", Message: "period must be all, week or month"}
+	}
+	if limit < 1 || limit > maxLeaderboardLimit {
+		limit = defaultLeaderboardLimit
+	}
+
+	scores, err := s.repo.GetTopScores(viewerID, scope == models.LeaderboardScopeFriends, since, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]models.LeaderboardEntry, len(scores))
+	for i, score := range scores {
+		rank := i + 1
+		if i > 0 && score.Score == scores[i-1].Score {
+			rank = entries[i-1].Rank
+		}
+		entries[i] = models.LeaderboardEntry{Rank: rank, UserScore: score}
+	}
+
+	return &models.GetLeaderboardResponse{Scope: scope, Period: period, Entries: entries}, nil
+}
+
+// GetTasteScoreHistory breaks the user's taste score down over the last days
+// calendar days in the given IANA time zone (UTC if empty): the daily change
+// and end-of-day score, oldest first, and the net score each post earned in
+// that window.
+func (s *UserService) GetTasteScoreHistory(userID string, days int, timeZone string) (*models.TasteScoreHistoryResponse, error) {
+	if days < 1 || days > maxTasteScoreHistoryDays {
+		days = defaultTasteScoreHistoryDays
+	}
+	if timeZone == "" {
+		timeZone = "UTC"
+	}
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil, &ValidationError{Field: "tz", Message: "tz must be an IANA time zone name"}
+	}
+
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	start := today.AddDate(0, 0, -(days - 1))
+
+	events, err := s.repo.GetTasteScoreEvents(userID, start)
+	if err != nil {
+		return nil, err
+	}
+	contributions, err := s.repo.GetTasteScoreByPost(userID, start)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]models.TasteScoreDay, days)
+	index := make(map[string]int, days)
+	for i := range history {
+		date := start.AddDate(0, 0, i).Format("2006-01-02")
+		history[i].Date = date
+		index[date] = i
+	}
+	for _, event := range events {
+		if i, ok := index[event.CreatedAt.In(loc).Format("2006-01-02")]; ok {
+			history[i].Delta += event.Delta
+		}
+	}
+
+	// Walk back from the current score to get each day's closing score
+	score := user.TasteScore
+	for i := len(history) - 1; i >= 0; i-- {
+		history[i].Score = score
+		score -= history[i].Delta
+	}
+
+	return &models.TasteScoreHistoryResponse{
+		TasteScore: user.TasteScore,
+		TimeZone:   loc.String(),
+		Days:       history,
+		Posts:      contributions,
+	}, nil
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/batku/beerreal/internal/models"
+	"github.com/batku/beerreal/internal/repository"
+)
+
+const (
+	maxVenuePlaceIDLength = 255
+	maxVenueNameLength    = 100
+	maxVenueAddressLength = 255
+
+	defaultVenueLeaderboardLimit = 10
+	maxVenueLeaderboardLimit     = 50
+)
+
+var ErrVenueNotFound = errors.New("venue not found")
+
+type VenueService struct {
+	repo     repository.VenueRepository
+	postRepo repository.PostRepository
+}
+
+func NewVenueService(repo repository.VenueRepository, postRepo repository.PostRepository) *VenueService {
+	return &VenueService{repo: repo, postRepo: postRepo}
+}
+
+// UpsertVenue registers the venue behind a Google Places result so posts can
+// reference it by ID. Registering a known place ID returns the existing venue.
+func (s *VenueService) UpsertVenue(req *models.UpsertVenueRequest) (*models.Venue, error) {
+	placeID := strings.TrimSpace(req.PlaceID)
+	if placeID == "" || len(placeID) > maxVenuePlaceIDLength {
+		return nil, &ValidationError{Field: "placeId", Message: fmt.Sprintf("placeId must be 1-%d characters", maxVenuePlaceIDLength)}
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxVenueNameLength {
+		return nil, &ValidationError{Field: "name", Message: fmt.Sprintf("name must be 1-%d characters", maxVenueNameLength)}
+	}
+
+	var address *string
+	if req.Address != nil {
+		trimmed := strings.TrimSpace(*req.Address)
+		if utf8.RuneCountInString(trimmed) > maxVenueAddressLength {
+			return nil, &ValidationError{Field: "address", Message: fmt.Sprintf("address must be at most %d characters", maxVenueAddressLength)}
+		}
+		if trimmed != "" {
+			address = &trimmed
+		}
+	}
+
+	lat, lng := *req.Latitude, *req.Longitude
+	if math.IsNaN(lat) || lat < -90 || lat > 90 {
+		return nil, &ValidationError{Field: "latitude", Message: "latitude must be between -90 and 90"}
+	}
+	if math.IsNaN(lng) || lng < -180 || lng > 180 {
+		return nil, &ValidationError{Field: "longitude", Message: "longitude must be between -180 and 180"}
+	}
+
+	venue := &models.Venue{PlaceID: placeID, Name: name, Address: address, Latitude: lat, Longitude: lng}
+	if err := s.repo.UpsertVenue(venue); err != nil {
+		return nil, err
+	}
+	return venue, nil
+}
+
+// GetVenue returns a venue with aggregate stats and a page of the posts made
+// there that the viewer can see, most recent first.
+func (s *VenueService) GetVenue(venueID, viewerID string, page, pageSize int) (*models.GetVenueResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	venue, err := s.repo.GetVenueByID(venueID)
+	if err != nil {
+		return nil, err
+	}
+	if venue == nil {
+		return nil, ErrVenueNotFound
+	}
+
+	stats, err := s.postRepo.GetVenueStats(venueID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := (page - 1) * pageSize
+	posts, totalCount, err := s.postRepo.GetVenuePosts(venueID, viewerID, pageSize, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get venue posts: %w", err)
+	}
+
+	return &models.GetVenueResponse{
+		Venue:      *venue,
+		Stats:      *stats,
+		Posts:      posts,
+		TotalCount: totalCount,
+		Page:       page,
+		PageSize:   pageSize,
+	}, nil
+}
+
+// GetVenueLeaderboard ranks users by how many posts they have made at the
+// venue during the period. Users with equal counts share a rank.
+func (s *VenueService) GetVenueLeaderboard(venueID, viewerID string, period models.LeaderboardPeriod, limit int) (*models.GetVenueLeaderboardResponse, error) {
+	if period == "" {
+		period = models.LeaderboardPeriodMonth
+	}
+	since, ok := leaderboardSince(period, time.Now())
+	if !ok {
+		return nil, &ValidationError{Field: "period", Message: "period must be all, week or month"}
+	}
+	if limit < 1 || limit > maxVenueLeaderboardLimit {
+		limit = defaultVenueLeaderboardLimit
+	}
+
+	venue, err := s.repo.GetVenueByID(venueID)
+	if err != nil {
+		return nil, err
+	}
+	if venue == nil {
+		return nil, ErrVenueNotFound
+	}
+
+	visitors, err := s.postRepo.GetVenueTopVisitors(venueID, viewerID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]models.VenueLeaderboardEntry, len(visitors))
+	for i, visitor := range visitors {
+		rank := i + 1
+		if i > 0 && visitor.PostCount == visitors[i-1].PostCount {
+			rank = entries[i-1].Rank
+		}
+		entries[i] = models.VenueLeaderboardEntry{Rank: rank, VenueVisitor: visitor}
+	}
+
+	return &models.GetVenueLeaderboardResponse{VenueID: venue.ID, Period: period, Entries: entries}, nil
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/batku/beerreal/internal/models"
+	"github.com/batku/beerreal/internal/repository"
+)
+
+const (
+	defaultVoteListLimit = 20
+	maxVoteListLimit     = 100
+)
+
+// ErrVotersHidden is returned when voter lists are author-only and the caller
+// is not the post's author.
+var ErrVotersHidden = errors.New("only the post's author can see who voted")
+
+type VoteService struct {
+	postRepo repository.PostRepository
+	// votersPublic lets everyone who can see a post list its voters.
+	votersPublic bool
+}
+
+func NewVoteService(postRepo repository.PostRepository, votersPublic bool) *VoteService {
+	return &VoteService{postRepo: postRepo, votersPublic: votersPublic}
+}
+
+// GetPostVoters returns a page of the post's voters, most recent vote first,
+// with the cursor for the next page.
+func (s *VoteService) GetPostVoters(postID, viewerID string, voteType models.VoteType, cursor string, limit int) (*models.GetPostVotesResponse, error) {
+	if voteType != "" && voteType != models.VoteTypeUpvote && voteType != models.VoteTypeDownvote {
+		return nil, &ValidationError{Field: "type", Message: "type must be UPVOTE or DOWNVOTE"}
+	}
+	if limit < 1 || limit > maxVoteListLimit {
+		limit = defaultVoteListLimit
+	}
+	before, err := parseVoteCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	post, err := s.postRepo.GetPostByID(postID, viewerID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPostNotFound) {
+			return nil, ErrPostNotFound
+		}
+		return nil, fmt.Errorf("failed to get post: %w", err)
+	}
+	if post == nil {
+		return nil, ErrPostNotFound
+	}
+	if !s.votersPublic && post.UserID != viewerID {
+		return nil, ErrVotersHidden
+	}
+
+	// Fetch one extra to know whether there is another page
+	voters, err := s.postRepo.GetPostVoters(postID, viewerID, voteType, before, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	var nextCursor *string
+	if len(voters) > limit {
+		voters = voters[:limit]
+		last := voters[limit-1]
+		next := encodeVoteCursor(repository.VoteCursor{VotedAt: last.VotedAt, ID: last.VoteID})
+		nextCursor = &next
+	}
+
+	return &models.GetPostVotesResponse{Voters: voters, NextCursor: nextCursor}, nil
+}
+
+// GetVoteHistory returns a page of the posts the user voted on, most recent
+// vote first, with the cursor for the next page. Posts the user can no longer
+// see are left out.
+func (s *VoteService) GetVoteHistory(userID, cursor string, limit int) (*models.GetVoteHistoryResponse, error) {
+	if limit < 1 || limit > maxVoteListLimit {
+		limit = defaultVoteListLimit
+	}
+	before, err := parseVoteCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch one extra to know whether there is another page
+	votes, err := s.postRepo.GetVotedPosts(userID, before, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	var nextCursor *string
+	if len(votes) > limit {
+		votes = votes[:limit]
+		last := votes[limit-1]
+		next := encodeVoteCursor(repository.VoteCursor{VotedAt: last.VotedAt, ID: last.VoteID})
+		nextCursor = &next
+	}
+
+	return &models.GetVoteHistoryResponse{Votes: votes, NextCursor: nextCursor}, nil
+}
+
+// parseVoteCursor decodes a cursor query parameter; an empty cursor starts
+// from the most recent vote.
+func parseVoteCursor(cursor string) (*repository.VoteCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	decoded, err := decodeVoteCursor(cursor)
+	if err != nil {
+		return nil, &ValidationError{Field: "cursor", Message: "invalid cursor"}
+	}
+	return decoded, nil
+}
+
+func encodeVoteCursor(cursor repository.VoteCursor) string {
+	raw := cursor.VotedAt.UTC().Format(time.RFC3339Nano) + "|" + cursor.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeVoteCursor(cursor string) (*repository.VoteCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+	votedAt, id, found := strings.Cut(string(raw), "|")
+	if !found || id == "" {
+		return nil, errors.New("malformed cursor")
+	}
+	t, err := time.Parse(time.RFC3339Nano, votedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &repository.VoteCursor{VotedAt: t, ID: id}, nil
+}
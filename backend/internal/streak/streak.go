@@ -0,0 +1,59 @@
+// Package streak computes posting streaks, which count consecutive calendar
+// days with a post in the user's own time zone. It is shared by the service
+// layer and the database migration that backfills streaks.
+package streak
+
+import (
+	"time"
+
+	"github.com/batku/beerreal/internal/models"
+)
+
+// DateLayout is the format of users.last_post_date.
+const DateLayout = "2006-01-02"
+
+// Location loads the user's time zone, falling back to UTC if it is unknown.
+func Location(timeZone string) *time.Location {
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// LocalDate returns t's calendar date in loc, offset by the given number of
+// days.
+func LocalDate(t time.Time, loc *time.Location, offsetDays int) string {
+	local := t.In(loc)
+	// Noon avoids landing on a wall time skipped by a DST change
+	return time.Date(local.Year(), local.Month(), local.Day()+offsetDays, 12, 0, 0, 0, loc).Format(DateLayout)
+}
+
+// Advance records a post made at postTime. A post on the day after the last
+// one extends the streak, a later one starts a new streak, and further posts
+// on the same day change nothing. It reports whether the streak changed.
+func Advance(streak *models.UserStreak, postTime time.Time) bool {
+	loc := Location(streak.TimeZone)
+	today := LocalDate(postTime, loc, 0)
+	if streak.LastPostDate != nil && *streak.LastPostDate == today {
+		return false
+	}
+
+	if streak.LastPostDate != nil && *streak.LastPostDate == LocalDate(postTime, loc, -1) {
+		streak.CurrentStreak++
+	} else {
+		streak.CurrentStreak = 1
+	}
+	streak.LongestStreak = max(streak.LongestStreak, streak.CurrentStreak)
+	streak.LastPostDate = &today
+	return true
+}
+
+// Broken reports whether the user has missed a full day since their last
+// post, as of now in their time zone.
+func Broken(streak models.UserStreak, now time.Time) bool {
+	if streak.LastPostDate == nil {
+		return true
+	}
+	return *streak.LastPostDate < LocalDate(now, Location(streak.TimeZone), -1)
+}